@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -10,9 +11,11 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/cli-runtime/pkg/printers"
 
 	corev1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	"github.com/raffis/kjournal/pkg/client/clientset/versioned"
 )
 
 type auditFlags struct {
@@ -154,3 +157,17 @@ func (h auditEventListAdapter) asClientList() ObjectList {
 func (h auditEventListAdapter) len() int {
 	return len(h.AuditEventList.Items)
 }
+
+func (h auditEventListAdapter) list(ctx context.Context, client versioned.Interface, _ string, opts metav1.ListOptions) error {
+	list, err := client.CoreV1alpha1().AuditEvents().List(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	*h.AuditEventList = *list
+	return nil
+}
+
+func (h auditEventListAdapter) watch(ctx context.Context, client versioned.Interface, _ string, opts metav1.ListOptions) (watch.Interface, error) {
+	return client.CoreV1alpha1().AuditEvents().Watch(ctx, opts)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -12,9 +13,11 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/cli-runtime/pkg/printers"
 
 	corev1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	"github.com/raffis/kjournal/pkg/client/clientset/versioned"
 )
 
 type eventsFlags struct {
@@ -37,7 +40,10 @@ var eventsCmd = &cobra.Command{
   kjournal events -n mynamespace deployments
   
   # Stream events for a pod named abc
-  kjournal events -n mynamespace pods/abc`,
+  kjournal events -n mynamespace pods/abc
+
+  # Stream events from all namespaces
+  kjournal events -A`,
 	//ValidArgsFunction: resourceNamesCompletionFunc(eventsv1.GroupVersion.WithKind(corev1alpha1.EventKind)),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		get := getCommand{
@@ -54,6 +60,7 @@ var eventsCmd = &cobra.Command{
 
 func init() {
 	addGetFlags(eventsCmd)
+	addNamespaceFlags(eventsCmd)
 	eventsCmd.PersistentFlags().BoolVarP(&eventsArgs.noHeader, "no-header", "", false, "skip the header when printing the results")
 
 	rootCmd.AddCommand(eventsCmd)
@@ -109,7 +116,16 @@ func (cmd *eventsCommand) defaultPrinter(obj runtime.Object) error {
 	for _, item := range list.Items {
 		if cmd.printer == nil {
 			cmd.printer = printers.GetNewTabWriter(cmd.cmd.OutOrStdout())
-			fmt.Fprintln(cmd.printer, strings.Join([]string{"LAST SEEN", "TYPE", "REASON", "OBJECT", "MESSAGE"}, "\t"))
+			columns := []string{"LAST SEEN", "TYPE", "REASON", "OBJECT", "MESSAGE"}
+			if namespaceArgs.multiNamespace() {
+				columns = append([]string{"NAMESPACE"}, columns...)
+			}
+
+			fmt.Fprintln(cmd.printer, strings.Join(columns, "\t"))
+		}
+
+		if namespaceArgs.multiNamespace() {
+			fmt.Fprintf(cmd.printer, "%s\t", item.Namespace)
 		}
 
 		fmt.Fprintf(cmd.printer, "%s\t%s\t%s\t%s/%s\t%s\n",
@@ -152,6 +168,20 @@ func (h eventListAdapter) len() int {
 	return len(h.EventList.Items)
 }
 
+func (h eventListAdapter) list(ctx context.Context, client versioned.Interface, namespace string, opts metav1.ListOptions) error {
+	list, err := client.CoreV1alpha1().Events(namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	*h.EventList = *list
+	return nil
+}
+
+func (h eventListAdapter) watch(ctx context.Context, client versioned.Interface, namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return client.CoreV1alpha1().Events(namespace).Watch(ctx, opts)
+}
+
 func (cmd *eventsCommand) getInterval(e corev1alpha1.Event) string {
 	var interval string
 	firstTimestampSince := translateMicroTimestampSince(e.EventTime)
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// annotationNode is the annotation which holds the node name of a log in the bundled simple apiserver configs
+	annotationNode = "host"
+
+	// labelNode is the label which holds the node name of a log in the bundled structured apiserver config
+	labelNode = "node"
+)
+
+type logFormatFlags struct {
+	format          string
+	template        string
+	templateFile    string
+	noColor         bool
+	timestamp       bool
+	nodePrefix      bool
+	namespacePrefix bool
+}
+
+var logFormatArgs logFormatFlags
+
+// logFormats are the built-in templates of the default output
+var logFormats = map[string]string{
+	"raw":        `{{.Message}}`,
+	"short":      `{{with .PodName}}{{color $.PodColor .}} {{end}}{{with .ContainerName}}{{color $.ContainerColor .}} {{end}}{{.Message}}`,
+	"long":       `{{.Timestamp}} {{with .Namespace}}{{.}} {{end}}{{with .NodeName}}{{.}} {{end}}{{with .PodName}}{{color $.PodColor .}} {{end}}{{with .ContainerName}}{{color $.ContainerColor .}} {{end}}{{.Message}}`,
+	"logfmt":     `ts={{.Timestamp}}{{with .Namespace}} namespace={{quote .}}{{end}}{{with .NodeName}} node={{quote .}}{{end}}{{with .PodName}} pod={{quote .}}{{end}}{{with .ContainerName}} container={{quote .}}{{end}} msg={{quote .Message}}`,
+	"json-lines": `{{json .Object}}`,
+}
+
+// prefixFormats are the built-in formats which are prefixed with the timestamp, namespace and node if requested
+var prefixFormats = map[string]bool{
+	"raw":   true,
+	"short": true,
+}
+
+func addLogFormatFlags(cmd *cobra.Command) {
+	var formats []string
+	for name := range logFormats {
+		formats = append(formats, name)
+	}
+
+	sort.Strings(formats)
+
+	cmd.PersistentFlags().StringVarP(&logFormatArgs.format, "format", "", "short", fmt.Sprintf("Format of the default output. One of: (%s)", strings.Join(formats, ", ")))
+	cmd.PersistentFlags().StringVarP(&logFormatArgs.template, "template", "", "", "Golang template used for the default output, it has precedence over --format. (e.g. `--template='{{.PodName}} {{.Message}}'`)")
+	cmd.PersistentFlags().StringVarP(&logFormatArgs.templateFile, "template-file", "", "", "Path to a golang template file used for the default output")
+	cmd.PersistentFlags().BoolVarP(&logFormatArgs.noColor, "no-color", "", false, "Don't use colors in the default output")
+	cmd.PersistentFlags().BoolVarP(&logFormatArgs.timestamp, "timestamp", "t", false, "Print creationTime timestamp in the default output.")
+	cmd.PersistentFlags().BoolVarP(&logFormatArgs.nodePrefix, "node-prefix", "", false, "Print the node name in the default output")
+	cmd.PersistentFlags().BoolVarP(&logFormatArgs.namespacePrefix, "namespace-prefix", "", false, "Print the namespace in the default output")
+}
+
+// Log is the object which will be used together with the template to generate
+// the output.
+type Log struct {
+	// Message is the log message itself
+	Message string `json:"message"`
+
+	// Timestamp is the creation timestamp of the log in RFC3339
+	Timestamp string `json:"timestamp"`
+
+	// Node name of the pod
+	NodeName string `json:"nodeName"`
+
+	// Namespace of the pod
+	Namespace string `json:"namespace"`
+
+	// PodName of the pod
+	PodName string `json:"podName"`
+
+	// ContainerName of the pods
+	ContainerName string `json:"podsName"`
+
+	// Object is the received object
+	Object interface{} `json:"-"`
+
+	PodColor       *color.Color `json:"-"`
+	ContainerColor *color.Color `json:"-"`
+}
+
+// logPrinter renders logs using the template of the default output
+type logPrinter struct {
+	template *template.Template
+	location *time.Location
+}
+
+// newLogPrinter parses the template from the format flags
+func newLogPrinter(args logFormatFlags) (*logPrinter, error) {
+	if args.template != "" && args.templateFile != "" {
+		return nil, errors.New("--template and --template-file are mutually exclusive")
+	}
+
+	if args.noColor {
+		color.NoColor = true
+	}
+
+	loc, err := timeLocation(getArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := logTemplate(args)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := template.New("log").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse log template: %w", err)
+	}
+
+	return &logPrinter{
+		template: t,
+		location: loc,
+	}, nil
+}
+
+// logTemplate returns the template text, built-in formats get prefixed with the requested fields
+func logTemplate(args logFormatFlags) (string, error) {
+	if args.template != "" {
+		return args.template, nil
+	}
+
+	if args.templateFile != "" {
+		b, err := os.ReadFile(args.templateFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read log template: %w", err)
+		}
+
+		return strings.TrimSuffix(string(b), "\n"), nil
+	}
+
+	text, ok := logFormats[args.format]
+	if !ok {
+		return "", fmt.Errorf("unknown format %s", args.format)
+	}
+
+	if !prefixFormats[args.format] {
+		return text, nil
+	}
+
+	var prefix string
+	if args.timestamp {
+		prefix += "{{.Timestamp}} "
+	}
+
+	if args.namespacePrefix {
+		prefix += "{{with .Namespace}}{{.}} {{end}}"
+	}
+
+	if args.nodePrefix {
+		prefix += "{{with .NodeName}}{{.}} {{end}}"
+	}
+
+	return prefix + text, nil
+}
+
+// newLog returns the template object of a log with its metadata
+func (p *logPrinter) newLog(meta metav1.ObjectMeta, payload json.RawMessage, obj interface{}) Log {
+	return Log{
+		Message:   string(payload),
+		Timestamp: meta.CreationTimestamp.In(p.location).Format(time.RFC3339),
+		NodeName:  nodeName(meta),
+		Namespace: meta.Namespace,
+		Object:    obj,
+	}
+}
+
+// nodeName returns the node name of a log from the field it is mapped to
+func nodeName(meta metav1.ObjectMeta) string {
+	if node, ok := meta.Annotations[annotationNode]; ok {
+		return node
+	}
+
+	return meta.Labels[labelNode]
+}
+
+// print renders the log and writes it to stdout
+func (p *logPrinter) print(vm Log) error {
+	var buf bytes.Buffer
+	if err := p.template.Execute(&buf, vm); err != nil {
+		return fmt.Errorf("failed to execute log template: %w", err)
+	}
+
+	fmt.Println(buf.String())
+	return nil
+}
+
+var templateFuncs = map[string]interface{}{
+	"json": func(in interface{}) (string, error) {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	},
+	"parseJSON": func(text string) (map[string]interface{}, error) {
+		obj := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(text), &obj); err != nil {
+			return obj, err
+		}
+		return obj, nil
+	},
+	"color": func(color color.Color, text string) string {
+		return color.SprintFunc()(text)
+	},
+	"quote": quote,
+}
+
+// quote quotes a logfmt value if it contains spaces, quotes or equal signs
+func quote(text string) string {
+	if text == "" || strings.ContainsAny(text, " \t\n\"=") {
+		return strconv.Quote(text)
+	}
+
+	return text
+}
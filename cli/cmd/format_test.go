@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeName(t *testing.T) {
+	tests := []struct {
+		name     string
+		meta     metav1.ObjectMeta
+		expected string
+	}{
+		{
+			name: "Node from the host annotation of the simple configs",
+			meta: metav1.ObjectMeta{
+				Annotations: map[string]string{"host": "node-a"},
+			},
+			expected: "node-a",
+		},
+		{
+			name: "Node from the node label of the structured config",
+			meta: metav1.ObjectMeta{
+				Labels: map[string]string{"node": "node-b", "app": "foo"},
+			},
+			expected: "node-b",
+		},
+		{
+			name: "Host annotation has precedence",
+			meta: metav1.ObjectMeta{
+				Annotations: map[string]string{"host": "node-a"},
+				Labels:      map[string]string{"node": "node-b"},
+			},
+			expected: "node-a",
+		},
+		{
+			name: "No node",
+			meta: metav1.ObjectMeta{
+				Labels: map[string]string{"app": "foo"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, nodeName(test.meta))
+		})
+	}
+}
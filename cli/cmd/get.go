@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,7 +14,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/rest"
@@ -22,18 +22,32 @@ import (
 	k8sget "k8s.io/kubectl/pkg/cmd/get"
 	"k8s.io/kubectl/pkg/util/interrupt"
 
-	corev1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	"github.com/raffis/kjournal/pkg/client/clientset/versioned"
 )
 
 type GetFlags struct {
-	fieldSelector string
-	watch         bool
-	chunkSize     string
-	since         string
-	timeRange     string
+	fieldSelector     string
+	watch             bool
+	chunkSize         string
+	since             string
+	timeRange         string
+	sinceTime         string
+	until             string
+	around            string
+	window            time.Duration
+	timezone          string
+	sourceCluster     string
+	allSourceClusters bool
 }
 
 var getArgs GetFlags
+
+type namespaceFlags struct {
+	allNamespaces bool
+	namespaces    string
+}
+
+var namespaceArgs namespaceFlags
 var printFlags *k8sget.PrintFlags
 
 func addGetFlags(getCmd *cobra.Command) {
@@ -44,18 +58,26 @@ func addGetFlags(getCmd *cobra.Command) {
 	getCmd.Flags().StringVarP(printFlags.OutputFormat, "output", "o", *printFlags.OutputFormat, fmt.Sprintf(`Output format. One of: (%s). See custom columns [https://kubernetes.io/docs/reference/kubectl/overview/#custom-columns], golang template [http://golang.org/pkg/text/template/#pkg-overview] and jsonpath template [https://kubernetes.io/docs/reference/kubectl/jsonpath/].`, strings.Join(printFlags.AllowedFormats(), ", ")))
 	getCmd.PersistentFlags().StringVarP(&getArgs.since, "since", "", "", "Change the time range from which logs are received. (e.g. `--since=24h`)")
 	getCmd.PersistentFlags().StringVarP(&getArgs.timeRange, "range", "", "", "Change the time range from which logs are received. (e.g. `--range=20h-24h`)")
+	getCmd.PersistentFlags().StringVarP(&getArgs.sinceTime, "since-time", "", "", "Receive logs after the given time, either RFC3339 or a local time. (e.g. `--since-time='2022-10-17 07:20'`)")
+	getCmd.PersistentFlags().StringVarP(&getArgs.until, "until", "", "", "Receive logs before the given time, either RFC3339, a local time or a duration such as 2h. (e.g. `--until='2022-10-17 08:00'`)")
+	getCmd.PersistentFlags().StringVarP(&getArgs.around, "around", "", "", "Receive logs within the window around the given time, either RFC3339 or a local time. (e.g. `--around='2022-10-17 07:20' --window=5m`)")
+	getCmd.PersistentFlags().DurationVarP(&getArgs.window, "window", "", 10*time.Minute, "The window before and after the time given by --around")
+	getCmd.PersistentFlags().StringVarP(&getArgs.timezone, "timezone", "", "", "Time zone of local times, defaults to the local time zone. (e.g. `--timezone=Europe/Zurich`)")
 	getCmd.PersistentFlags().BoolVarP(&getArgs.watch, "watch", "w", true, "After dumping all existing logs keep watching for newly added ones")
 	getCmd.PersistentFlags().StringVar(&getArgs.fieldSelector, "field-selector", "", "Selector (field query) to filter on, supports '=', '==', '!=', '!=', '>' and '<'. (e.g. --field-selector key1=value1,key2=value2).")
 	getCmd.PersistentFlags().StringVarP(&getArgs.chunkSize, "chunk-size", "", "500", "Return large lists in chunks rather than all at once. Pass 0 to disable. This has no impact as long as --watch=false is not set.")
+	getCmd.PersistentFlags().StringVarP(&getArgs.sourceCluster, "source-cluster", "", "", "Receive objects shipped from the given kubernetes clusters instead of the default cluster, you may add multiple ones comma separated. (e.g. `--source-cluster=prod-a,prod-b`)")
+	getCmd.PersistentFlags().BoolVarP(&getArgs.allSourceClusters, "all-source-clusters", "", false, "Receive objects shipped from all kubernetes clusters")
 }
 
-// Create the Scheme, methods for serializing and deserializing API objects
-// which can be shared by tests.
-func newScheme() *runtime.Scheme {
-	scheme := runtime.NewScheme()
-	_ = corev1alpha1.AddToScheme(scheme)
+func addNamespaceFlags(getCmd *cobra.Command) {
+	getCmd.PersistentFlags().BoolVarP(&namespaceArgs.allNamespaces, "all-namespaces", "A", false, "Receive objects from all namespaces")
+	getCmd.PersistentFlags().StringVarP(&namespaceArgs.namespaces, "namespaces", "", "", "Receive objects from the given namespaces, you may add multiple ones comma separated. (e.g. `--namespaces=a,b`)")
+}
 
-	return scheme
+// multiNamespace returns true if objects are received from more than the namespace of the kubeconfig
+func (n namespaceFlags) multiNamespace() bool {
+	return n.allNamespaces || n.namespaces != ""
 }
 
 func KubeConfig(rcg genericclioptions.RESTClientGetter, opts *Options) (*rest.Config, error) {
@@ -89,45 +111,91 @@ func (get getCommand) run(cmd *cobra.Command, args []string) error {
 	return get.streamObjects(cmd, args)
 }
 
-func (get getCommand) getClient() (*rest.RESTClient, error) {
+func (get getCommand) getClient() (versioned.Interface, error) {
 	cfg, err := KubeConfig(kubeconfigArgs, kubeclientOptions)
 	if err != nil {
 		return nil, err
 	}
 
-	cfg.GroupVersion = &get.groupVersion
-	var Scheme = newScheme()
-	var codecs = serializer.NewCodecFactory(Scheme)
-	cfg.NegotiatedSerializer = codecs.WithoutConversion()
-	cfg.APIPath = "/apis"
-
-	return rest.RESTClientFor(cfg)
+	return versioned.NewForConfig(cfg)
 }
 
-func (get getCommand) prepareRequest(args []string) (*rest.Request, error) {
-	c, err := get.getClient()
-	if err != nil {
-		return nil, err
-	}
-
+func (get getCommand) listOptions(args []string) (metav1.ListOptions, error) {
 	var opts metav1.ListOptions
 	opts.FieldSelector = getArgs.fieldSelector
 
-	err = get.command.filter(args, &opts)
+	if err := get.command.filter(args, &opts); err != nil {
+		return opts, err
+	}
+
+	selectors, err := clusterSelectors(getArgs)
 	if err != nil {
-		return nil, err
+		return opts, err
+	}
+
+	nsSelectors, err := namespaceSelectors(namespaceArgs)
+	if err != nil {
+		return opts, err
+	}
+
+	selectors = append(selectors, nsSelectors...)
+
+	if len(selectors) > 0 {
+		if opts.FieldSelector != "" {
+			selectors = append([]string{opts.FieldSelector}, selectors...)
+		}
+
+		opts.FieldSelector = strings.Join(selectors, ",")
+	}
+
+	return opts, nil
+}
+
+// namespaceSelectors returns the selectors of --namespaces, the namespaces are queried using a cluster wide request
+func namespaceSelectors(namespaceArgs namespaceFlags) (selectors []string, err error) {
+	if namespaceArgs.allNamespaces && namespaceArgs.namespaces != "" {
+		return selectors, errors.New("--namespaces and --all-namespaces are mutually exclusive")
+	}
+
+	if namespaceArgs.namespaces == "" {
+		return selectors, nil
 	}
 
-	r := c.
-		Get().
-		Resource(get.resource).
-		Param("fieldSelector", opts.FieldSelector)
+	namespaces := strings.Split(namespaceArgs.namespaces, ",")
+	if len(namespaces) == 1 {
+		return []string{fmt.Sprintf("metadata.namespace=%s", namespaces[0])}, nil
+	}
+
+	return []string{fmt.Sprintf("metadata.namespace in (%s)", strings.Join(namespaces, ","))}, nil
+}
+
+func clusterSelectors(getArgs GetFlags) (selectors []string, err error) {
+	if getArgs.allSourceClusters && getArgs.sourceCluster != "" {
+		return selectors, errors.New("--source-cluster and --all-source-clusters are mutually exclusive")
+	}
+
+	if getArgs.allSourceClusters {
+		return []string{"cluster"}, nil
+	}
+
+	if getArgs.sourceCluster == "" {
+		return selectors, nil
+	}
+
+	clusters := strings.Split(getArgs.sourceCluster, ",")
+	if len(clusters) == 1 {
+		return []string{fmt.Sprintf("cluster=%s", clusters[0])}, nil
+	}
+
+	return []string{fmt.Sprintf("cluster in (%s)", strings.Join(clusters, ","))}, nil
+}
 
-	if get.apiType.namespaced {
-		r.Namespace(*kubeconfigArgs.Namespace)
+func (get getCommand) namespace() string {
+	if get.apiType.namespaced && !namespaceArgs.multiNamespace() {
+		return *kubeconfigArgs.Namespace
 	}
 
-	return r, nil
+	return ""
 }
 
 func parseTimestamp(ts string) (int64, error) {
@@ -139,16 +207,119 @@ func parseTimestamp(ts string) (int64, error) {
 	return time.Now().Unix()*1000 - duration.Milliseconds(), nil
 }
 
-func timeRange(getArgs GetFlags) (selectors []string, err error) {
+// localTimeLayouts are the accepted layouts of times without a time zone
+var localTimeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseTime parses an RFC3339 time, a local time in the given location or a duration relative to now
+func parseTime(value string, loc *time.Location) (time.Time, error) {
+	if ts, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return ts, nil
+	}
+
+	for _, layout := range localTimeLayouts {
+		if ts, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return ts, nil
+		}
+	}
+
+	if duration, err := str2duration.ParseDuration(value); err == nil {
+		return time.Now().Add(-duration), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %s, expected RFC3339 (2006-01-02T15:04:05Z07:00), a local time (2006-01-02 15:04:05) or a duration", value)
+}
+
+// timeLocation returns the time zone of local times, it defaults to the local time zone
+func timeLocation(getArgs GetFlags) (*time.Location, error) {
+	if getArgs.timezone == "" {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(getArgs.timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone: %w", err)
+	}
+
+	return loc, nil
+}
+
+// absoluteTimeRange translates --since, --since-time, --until and --around into timestamp selectors
+func absoluteTimeRange(getArgs GetFlags) (selectors []string, err error) {
+	loc, err := timeLocation(getArgs)
+	if err != nil {
+		return selectors, err
+	}
+
+	if getArgs.around != "" {
+		ts, err := parseTime(getArgs.around, loc)
+		if err != nil {
+			return selectors, err
+		}
+
+		return []string{
+			fmt.Sprintf("metadata.creationTimestamp>%d", ts.Add(-getArgs.window).UnixMilli()),
+			fmt.Sprintf("metadata.creationTimestamp<%d", ts.Add(getArgs.window).UnixMilli()),
+		}, nil
+	}
+
+	var from, until time.Time
 	if getArgs.since != "" {
 		ts, err := parseTimestamp(getArgs.since)
 		if err != nil {
 			return selectors, err
 		}
 
-		return []string{fmt.Sprintf("metadata.creationTimestamp>%d", ts)}, nil
-	} else if getArgs.timeRange != "" {
+		from = time.UnixMilli(ts)
+	} else if getArgs.sinceTime != "" {
+		from, err = parseTime(getArgs.sinceTime, loc)
+		if err != nil {
+			return selectors, err
+		}
+	}
+
+	if getArgs.until != "" {
+		until, err = parseTime(getArgs.until, loc)
+		if err != nil {
+			return selectors, err
+		}
+	}
+
+	if !from.IsZero() && !until.IsZero() && !until.After(from) {
+		return selectors, fmt.Errorf("--until %s is not after the start of the time range %s", until.Format(time.RFC3339), from.Format(time.RFC3339))
+	}
+
+	if !from.IsZero() {
+		selectors = append(selectors, fmt.Sprintf("metadata.creationTimestamp>%d", from.UnixMilli()))
+	}
+
+	if !until.IsZero() {
+		selectors = append(selectors, fmt.Sprintf("metadata.creationTimestamp<%d", until.UnixMilli()))
+	}
+
+	return selectors, nil
+}
+
+func timeRange(getArgs GetFlags) (selectors []string, err error) {
+	switch {
+	case getArgs.around != "" && (getArgs.since != "" || getArgs.timeRange != "" || getArgs.sinceTime != "" || getArgs.until != ""):
+		return selectors, errors.New("--around can not be combined with --since, --since-time, --until or --range")
+	case getArgs.timeRange != "" && (getArgs.since != "" || getArgs.sinceTime != "" || getArgs.until != ""):
+		return selectors, errors.New("--range can not be combined with --since, --since-time or --until")
+	case getArgs.since != "" && getArgs.sinceTime != "":
+		return selectors, errors.New("--since and --since-time are mutually exclusive")
+	}
+
+	if getArgs.timeRange != "" {
 		parts := strings.Split(getArgs.timeRange, "-")
+		if len(parts) != 2 {
+			return selectors, fmt.Errorf("invalid range %s, expected two durations (e.g. `--range=20h-24h`)", getArgs.timeRange)
+		}
 
 		fromTimestamp, err := parseTimestamp(parts[0])
 		if err != nil {
@@ -165,6 +336,10 @@ func timeRange(getArgs GetFlags) (selectors []string, err error) {
 		}, nil
 	}
 
+	if getArgs.since != "" || getArgs.sinceTime != "" || getArgs.until != "" || getArgs.around != "" {
+		return absoluteTimeRange(getArgs)
+	}
+
 	return selectors, nil
 }
 
@@ -173,16 +348,22 @@ func (get getCommand) listObjects(cmd *cobra.Command, args []string) error {
 	defer cancel()
 	res := get.list.asClientList()
 
-	r, err := get.prepareRequest(args)
+	client, err := get.getClient()
 	if err != nil {
 		return err
 	}
 
-	r.Param("limit", getArgs.chunkSize)
-	response := r.Do(ctx)
-	err = response.Into(res)
+	opts, err := get.listOptions(args)
+	if err != nil {
+		return err
+	}
 
+	opts.Limit, err = strconv.ParseInt(getArgs.chunkSize, 10, 64)
 	if err != nil {
+		return fmt.Errorf("invalid chunk size: %w", err)
+	}
+
+	if err := get.list.list(ctx, client, get.namespace(), opts); err != nil {
 		return err
 	}
 
@@ -220,7 +401,12 @@ func (get getCommand) streamObjects(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), rootArgs.timeout)
 	defer cancel()
 
-	r, err := get.prepareRequest(args)
+	client, err := get.getClient()
+	if err != nil {
+		return err
+	}
+
+	opts, err := get.listOptions(args)
 	if err != nil {
 		return err
 	}
@@ -230,13 +416,11 @@ func (get getCommand) streamObjects(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if getArgs.watch {
-		r.Param("watch", "true")
-	} else {
-		r.Param("limit", "-1")
+	if !getArgs.watch {
+		opts.Limit = -1
 	}
 
-	w, err := r.Watch(ctx)
+	w, err := get.list.watch(ctx, client, get.namespace(), opts)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseTime(t *testing.T) {
+	zurich, err := time.LoadLocation("Europe/Zurich")
+	assert.NilError(t, err)
+
+	tests := []struct {
+		name          string
+		value         string
+		loc           *time.Location
+		expected      time.Time
+		expectedError string
+	}{
+		{
+			name:     "RFC3339 time",
+			value:    "2022-10-17T07:20:00Z",
+			loc:      zurich,
+			expected: time.Date(2022, 10, 17, 7, 20, 0, 0, time.UTC),
+		},
+		{
+			name:     "RFC3339 time keeps its own offset",
+			value:    "2022-10-17T07:20:00+05:00",
+			loc:      zurich,
+			expected: time.Date(2022, 10, 17, 2, 20, 0, 0, time.UTC),
+		},
+		{
+			name:     "Local time is parsed in the given time zone",
+			value:    "2022-10-17 07:20",
+			loc:      zurich,
+			expected: time.Date(2022, 10, 17, 5, 20, 0, 0, time.UTC),
+		},
+		{
+			name:     "Local time respects daylight saving time",
+			value:    "2022-12-17T07:20:30",
+			loc:      zurich,
+			expected: time.Date(2022, 12, 17, 6, 20, 30, 0, time.UTC),
+		},
+		{
+			name:     "Local time in UTC",
+			value:    "2022-10-17 07:20:30",
+			loc:      time.UTC,
+			expected: time.Date(2022, 10, 17, 7, 20, 30, 0, time.UTC),
+		},
+		{
+			name:     "Date",
+			value:    "2022-10-17",
+			loc:      zurich,
+			expected: time.Date(2022, 10, 16, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "Invalid time",
+			value:         "yesterday",
+			loc:           zurich,
+			expectedError: "invalid time yesterday, expected RFC3339 (2006-01-02T15:04:05Z07:00), a local time (2006-01-02 15:04:05) or a duration",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ts, err := parseTime(test.value, test.loc)
+			if test.expectedError != "" {
+				assert.Error(t, err, test.expectedError)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Assert(t, test.expected.Equal(ts), "expected %s, got %s", test.expected, ts)
+		})
+	}
+}
+
+func TestParseTimeDuration(t *testing.T) {
+	before := time.Now().Add(-2 * time.Hour)
+	ts, err := parseTime("2h", time.UTC)
+	assert.NilError(t, err)
+	assert.Assert(t, !ts.Before(before) && ts.Before(before.Add(time.Minute)), "expected about %s, got %s", before, ts)
+}
+
+func TestTimeRange(t *testing.T) {
+	from := time.Date(2022, 10, 17, 5, 20, 0, 0, time.UTC)
+	until := time.Date(2022, 10, 17, 6, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		args          GetFlags
+		expected      []string
+		expectedError string
+	}{
+		{
+			name: "No time range",
+		},
+		{
+			name: "Since time and until in a time zone",
+			args: GetFlags{
+				sinceTime: "2022-10-17 07:20",
+				until:     "2022-10-17 08:00",
+				timezone:  "Europe/Zurich",
+			},
+			expected: []string{
+				fmt.Sprintf("metadata.creationTimestamp>%d", from.UnixMilli()),
+				fmt.Sprintf("metadata.creationTimestamp<%d", until.UnixMilli()),
+			},
+		},
+		{
+			name: "Until only",
+			args: GetFlags{
+				until:    "2022-10-17T06:00:00Z",
+				timezone: "Europe/Zurich",
+			},
+			expected: []string{
+				fmt.Sprintf("metadata.creationTimestamp<%d", until.UnixMilli()),
+			},
+		},
+		{
+			name: "Around in a time zone",
+			args: GetFlags{
+				around:   "2022-10-17 07:20",
+				window:   5 * time.Minute,
+				timezone: "Europe/Zurich",
+			},
+			expected: []string{
+				fmt.Sprintf("metadata.creationTimestamp>%d", from.Add(-5*time.Minute).UnixMilli()),
+				fmt.Sprintf("metadata.creationTimestamp<%d", from.Add(5*time.Minute).UnixMilli()),
+			},
+		},
+		{
+			name: "Around combined with since",
+			args: GetFlags{
+				around: "2022-10-17 07:20",
+				since:  "1h",
+				window: 5 * time.Minute,
+			},
+			expectedError: "--around can not be combined with --since, --since-time, --until or --range",
+		},
+		{
+			name: "Around combined with since time",
+			args: GetFlags{
+				around:    "2022-10-17 07:20",
+				sinceTime: "2022-10-17 07:00",
+				window:    5 * time.Minute,
+			},
+			expectedError: "--around can not be combined with --since, --since-time, --until or --range",
+		},
+		{
+			name: "Range combined with until",
+			args: GetFlags{
+				timeRange: "20h-24h",
+				until:     "2h",
+			},
+			expectedError: "--range can not be combined with --since, --since-time or --until",
+		},
+		{
+			name: "Since combined with since time",
+			args: GetFlags{
+				since:     "1h",
+				sinceTime: "2022-10-17 07:00",
+			},
+			expectedError: "--since and --since-time are mutually exclusive",
+		},
+		{
+			name: "Until before since time",
+			args: GetFlags{
+				sinceTime: "2022-10-17 08:00",
+				until:     "2022-10-17 07:20",
+				timezone:  "Europe/Zurich",
+			},
+			expectedError: "--until 2022-10-17T07:20:00+02:00 is not after the start of the time range 2022-10-17T08:00:00+02:00",
+		},
+		{
+			name: "Until equal to since time",
+			args: GetFlags{
+				sinceTime: "2022-10-17T06:00:00Z",
+				until:     "2022-10-17 08:00",
+				timezone:  "Europe/Zurich",
+			},
+			expectedError: "--until 2022-10-17T08:00:00+02:00 is not after the start of the time range 2022-10-17T06:00:00Z",
+		},
+		{
+			name: "Invalid time zone",
+			args: GetFlags{
+				sinceTime: "2022-10-17 07:20",
+				timezone:  "Mars/Olympus",
+			},
+			expectedError: "invalid time zone: unknown time zone Mars/Olympus",
+		},
+		{
+			name: "Invalid range",
+			args: GetFlags{
+				timeRange: "20h",
+			},
+			expectedError: "invalid range 20h, expected two durations (e.g. `--range=20h-24h`)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selectors, err := timeRange(test.args)
+			if test.expectedError != "" {
+				assert.Error(t, err, test.expectedError)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.DeepEqual(t, test.expected, selectors)
+		})
+	}
+}
+
+func TestTimeRangeUntilBeforeSince(t *testing.T) {
+	_, err := timeRange(GetFlags{
+		since: "1h",
+		until: "2h",
+	})
+	assert.ErrorContains(t, err, "is not after the start of the time range")
+
+	selectors, err := timeRange(GetFlags{
+		since: "2h",
+		until: "1h",
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(selectors), 2)
+}
@@ -1,25 +1,22 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"strings"
-	"text/template"
 
-	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	corev1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	"github.com/raffis/kjournal/pkg/client/clientset/versioned"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
 type logsFlags struct {
 	log string
-	//	noColor   bool
-	timestamp bool
 }
 
 var logsArgs logsFlags
@@ -32,8 +29,13 @@ var logCmd = &cobra.Command{
   kjoural log -n mynamespace`,
 	//ValidArgsFunction: resourceNamesCompletionFunc(logsv1beta1.GroupVersion.WithKind(logsv1beta1.LogKind)),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		printer, err := newLogPrinter(logFormatArgs)
+		if err != nil {
+			return err
+		}
+
 		get := getCommand{
-			command: &logsCommand{},
+			command: &logsCommand{printer: printer},
 			apiType: logAdapterType,
 			list:    &logListAdapter{&corev1alpha1.LogList{}},
 		}
@@ -43,13 +45,13 @@ var logCmd = &cobra.Command{
 }
 
 func init() {
-	logCmd.PersistentFlags().BoolVarP(&logsArgs.timestamp, "timestamp", "t", false, "Print creationTime timestamp in the default output.")
-
+	addLogFormatFlags(logCmd)
 	addGetFlags(logCmd)
 	rootCmd.AddCommand(logCmd)
 }
 
 type logsCommand struct {
+	printer *logPrinter
 }
 
 func (cmd *logsCommand) filter(args []string, opts *metav1.ListOptions) error {
@@ -78,58 +80,20 @@ func (cmd *logsCommand) filter(args []string, opts *metav1.ListOptions) error {
 }
 
 func (cmd *logsCommand) defaultPrinter(obj runtime.Object) error {
-	var list corev1alpha1.LogList
-	log, ok := obj.(*corev1alpha1.Log)
-	if ok {
+	list := &corev1alpha1.LogList{}
+
+	if log, ok := obj.(*corev1alpha1.Log); ok {
 		list.Items = append(list.Items, *log)
+	} else if obj, ok := obj.(*corev1alpha1.LogList); ok {
+		list = obj
 	}
 
 	for _, item := range list.Items {
-		if err := printLog(item); err != nil {
+		if err := cmd.printer.print(cmd.printer.newLog(item.ObjectMeta, item.Payload, item)); err != nil {
 			return err
 		}
 	}
-	return nil
-}
-
-// Print prints a color coded log message with the pod and container names
-func printLog(log corev1alpha1.Log) error {
-	vm := Log{
-		Message: string(log.Payload),
-	}
-
-	t := "{{.Message}}"
-
-	funs := map[string]interface{}{
-		"json": func(in interface{}) (string, error) {
-			b, err := json.Marshal(in)
-			if err != nil {
-				return "", err
-			}
-			return string(b), nil
-		},
-		"parseJSON": func(text string) (map[string]interface{}, error) {
-			obj := make(map[string]interface{})
-			if err := json.Unmarshal([]byte(text), &obj); err != nil {
-				return obj, err
-			}
-			return obj, nil
-		},
-		"color": func(color color.Color, text string) string {
-			return color.SprintFunc()(text)
-		},
-	}
-	template, err := template.New("log").Funcs(funs).Parse(t)
-	if err != nil {
-		return fmt.Errorf("failed to parse log template: %w", err)
-	}
-
-	var buf bytes.Buffer
-	if err := template.Execute(&buf, vm); err != nil {
-		return fmt.Errorf("failed to execute log template: %w", err)
-	}
 
-	fmt.Println(buf.String())
 	return nil
 }
 
@@ -154,3 +118,17 @@ func (h logListAdapter) asClientList() ObjectList {
 func (h logListAdapter) len() int {
 	return len(h.LogList.Items)
 }
+
+func (h logListAdapter) list(ctx context.Context, client versioned.Interface, _ string, opts metav1.ListOptions) error {
+	list, err := client.CoreV1alpha1().Logs().List(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	*h.LogList = *list
+	return nil
+}
+
+func (h logListAdapter) watch(ctx context.Context, client versioned.Interface, _ string, opts metav1.ListOptions) (watch.Interface, error) {
+	return client.CoreV1alpha1().Logs().Watch(ctx, opts)
+}
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/raffis/kjournal/pkg/client/clientset/versioned"
 )
 
 type apiType struct {
@@ -17,6 +22,8 @@ type apiType struct {
 type listAdapter interface {
 	asClientList() ObjectList
 	len() int
+	list(ctx context.Context, client versioned.Interface, namespace string, opts metav1.ListOptions) error
+	watch(ctx context.Context, client versioned.Interface, namespace string, opts metav1.ListOptions) (watch.Interface, error)
 }
 
 type ObjectList interface {
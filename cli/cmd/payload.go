@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+type payloadFlags struct {
+	jsonFields  string
+	filterLevel string
+	levelField  string
+}
+
+var payloadArgs payloadFlags
+
+// levelColors are the colors of the log levels
+var levelColors = map[string]*color.Color{
+	"panic":    color.New(color.FgHiRed, color.Bold),
+	"fatal":    color.New(color.FgHiRed, color.Bold),
+	"critical": color.New(color.FgHiRed, color.Bold),
+	"error":    color.New(color.FgRed),
+	"err":      color.New(color.FgRed),
+	"warning":  color.New(color.FgYellow),
+	"warn":     color.New(color.FgYellow),
+	"info":     color.New(color.FgGreen),
+	"debug":    color.New(color.FgBlue),
+	"trace":    color.New(color.FgHiBlack),
+}
+
+func addPayloadFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&payloadArgs.jsonFields, "json-field", "", "", "Parse JSON payloads and print the given fields only, nested fields are separated by dots. (e.g. `--json-field=level,msg`)")
+	cmd.PersistentFlags().StringVarP(&payloadArgs.filterLevel, "filter-level", "", "", "Only print logs with the given levels, you may add multiple ones comma separated. The levels are filtered server-side as well. (e.g. `--filter-level=error`)")
+	cmd.PersistentFlags().StringVarP(&payloadArgs.levelField, "level-field", "", "level", "The field of JSON payloads which holds the log level")
+}
+
+// payloadRenderer renders JSON payloads and filters them by their log level
+type payloadRenderer struct {
+	fields     []string
+	levels     map[string]bool
+	levelField string
+}
+
+// newPayloadRenderer returns a renderer for the payload flags, it is nil if the payload is printed unchanged
+func newPayloadRenderer(args payloadFlags) *payloadRenderer {
+	if args.jsonFields == "" && args.filterLevel == "" {
+		return nil
+	}
+
+	r := &payloadRenderer{
+		levels:     make(map[string]bool),
+		levelField: args.levelField,
+	}
+
+	if args.jsonFields != "" {
+		r.fields = strings.Split(args.jsonFields, ",")
+	}
+
+	if args.filterLevel != "" {
+		for _, level := range strings.Split(args.filterLevel, ",") {
+			r.levels[normalizeLevel(level)] = true
+		}
+	}
+
+	return r
+}
+
+// levelSelectors returns the field selector of the level filter.
+// The level field might be stored as keyword which is matched case-sensitive by the backend,
+// hence each level is pushed down in its lowercase, uppercase and capitalized variant.
+// The renderer filters the levels case-insensitive on top.
+func levelSelectors(args payloadFlags) []string {
+	if args.filterLevel == "" {
+		return nil
+	}
+
+	var values []string
+	seen := make(map[string]bool)
+	for _, level := range strings.Split(args.filterLevel, ",") {
+		level = normalizeLevel(level)
+		if level == "" {
+			continue
+		}
+
+		for _, value := range []string{level, strings.ToUpper(level), strings.ToUpper(level[:1]) + level[1:]} {
+			if !seen[value] {
+				seen[value] = true
+				values = append(values, value)
+			}
+		}
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	return []string{fmt.Sprintf("payload.%s in (%s)", args.levelField, strings.Join(values, ","))}
+}
+
+// normalizeLevel returns the lowercase level, levels are compared case-insensitive
+func normalizeLevel(level string) string {
+	return strings.ToLower(strings.TrimSpace(level))
+}
+
+// render returns the message of the payload, it returns false if the payload does not match the level filter.
+// Payloads which are not JSON are printed unchanged as long as no level filter is set.
+func (r *payloadRenderer) render(payload json.RawMessage) (string, bool) {
+	obj := make(map[string]interface{})
+	if err := json.Unmarshal(payload, &obj); err != nil {
+		return string(payload), len(r.levels) == 0
+	}
+
+	level := normalizeLevel(fmt.Sprint(lookupField(obj, r.levelField)))
+	if len(r.levels) > 0 && !r.levels[level] {
+		return "", false
+	}
+
+	if len(r.fields) == 0 {
+		if c, ok := levelColors[level]; ok {
+			return c.SprintFunc()(string(payload)), true
+		}
+
+		return string(payload), true
+	}
+
+	var parts []string
+	for _, field := range r.fields {
+		value := lookupField(obj, field)
+		if value == nil {
+			continue
+		}
+
+		text := formatValue(value)
+		if field == r.levelField {
+			if c, ok := levelColors[level]; ok {
+				text = c.SprintFunc()(text)
+			}
+		}
+
+		parts = append(parts, fmt.Sprintf("%s=%s", field, text))
+	}
+
+	return strings.Join(parts, " "), true
+}
+
+// lookupField returns the value of a field, nested fields are separated by dots
+func lookupField(obj map[string]interface{}, field string) interface{} {
+	if value, ok := obj[field]; ok {
+		return value
+	}
+
+	parts := strings.SplitN(field, ".", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+
+	if nested, ok := obj[parts[0]].(map[string]interface{}); ok {
+		return lookupField(nested, parts[1])
+	}
+
+	return nil
+}
+
+// formatValue quotes scalar values and pretty prints nested structures on the following lines
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return quote(v)
+	case map[string]interface{}, []interface{}:
+		b, err := json.MarshalIndent(v, "  ", "  ")
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+
+		return "\n  " + string(b)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
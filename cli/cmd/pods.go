@@ -1,12 +1,10 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"hash/fnv"
 	"strings"
-	"text/template"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -14,13 +12,13 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	corev1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	"github.com/raffis/kjournal/pkg/client/clientset/versioned"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
 type podsFlags struct {
-	pods      string
-	noColor   bool
-	timestamp bool
+	pods string
 }
 
 var podsArgs podsFlags
@@ -30,11 +28,23 @@ var podsCmd = &cobra.Command{
 	Short: "Get pod logs",
 	Long:  "The pods command prints logs from pods",
 	Example: `  # Print logs from all pods in the same namespace
-  kjoural pods -n mynamespace`,
+  kjoural pods -n mynamespace
+
+  # Print logs from all pods in the namespaces a and b
+  kjournal pods --namespaces a,b`,
 	//ValidArgsFunction: resourceNamesCompletionFunc(logsv1beta1.GroupVersion.WithKind(logsv1beta1.LogKind)),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if namespaceArgs.multiNamespace() {
+			logFormatArgs.namespacePrefix = true
+		}
+
+		printer, err := newLogPrinter(logFormatArgs)
+		if err != nil {
+			return err
+		}
+
 		get := getCommand{
-			command: &podsCommand{},
+			command: &podsCommand{printer: printer, payload: newPayloadRenderer(payloadArgs)},
 			apiType: podsLogAdapterType,
 			list:    &podsLogListAdapter{&corev1alpha1.ContainerLogList{}},
 		}
@@ -44,14 +54,17 @@ var podsCmd = &cobra.Command{
 
 func init() {
 	podsCmd.PersistentFlags().StringVarP(&podsArgs.pods, "pods", "c", "", "Only dump logs from pods names matching. (This is the same as --field-selector pods=name)")
-	podsCmd.PersistentFlags().BoolVarP(&podsArgs.noColor, "no-color", "", false, "Don't use colors in the default output")
-	podsCmd.PersistentFlags().BoolVarP(&podsArgs.timestamp, "timestamp", "t", false, "Print creationTime timestamp in the default output.")
 
+	addNamespaceFlags(podsCmd)
+	addLogFormatFlags(podsCmd)
+	addPayloadFlags(podsCmd)
 	addGetFlags(podsCmd)
 	rootCmd.AddCommand(podsCmd)
 }
 
 type podsCommand struct {
+	printer *logPrinter
+	payload *payloadRenderer
 }
 
 func (cmd *podsCommand) filter(args []string, opts *metav1.ListOptions) error {
@@ -75,6 +88,8 @@ func (cmd *podsCommand) filter(args []string, opts *metav1.ListOptions) error {
 		fieldSelector = append(fieldSelector, fmt.Sprintf("pods=%s", podsArgs.pods))
 	}
 
+	fieldSelector = append(fieldSelector, levelSelectors(payloadArgs)...)
+
 	opts.FieldSelector = strings.Join(fieldSelector, ",")
 	return nil
 }
@@ -89,7 +104,7 @@ func (cmd *podsCommand) defaultPrinter(obj runtime.Object) error {
 	}
 
 	for _, item := range list.Items {
-		if err := printContainerLog(item); err != nil {
+		if err := cmd.printContainerLog(item); err != nil {
 			return err
 		}
 	}
@@ -97,72 +112,25 @@ func (cmd *podsCommand) defaultPrinter(obj runtime.Object) error {
 	return nil
 }
 
-// Log is the object which will be used together with the template to generate
-// the output.
-type Log struct {
-	// Message is the log message itself
-	Message string `json:"message"`
-
-	// Node name of the pod
-	NodeName string `json:"nodeName"`
-
-	// Namespace of the pod
-	Namespace string `json:"namespace"`
-
-	// PodName of the pod
-	PodName string `json:"podName"`
-
-	// ContainerName of the pods
-	ContainerName string `json:"podsName"`
-
-	PodColor       *color.Color `json:"-"`
-	ContainerColor *color.Color `json:"-"`
-}
-
-// Print prints a color coded log message with the pod and pods names
-func printContainerLog(log corev1alpha1.ContainerLog) error {
-	podColor, podsColor := determineColor(log.Pod)
-	vm := Log{
-		Message:        string(log.Payload),
-		PodName:        log.Pod,
-		ContainerName:  log.Container,
-		PodColor:       podColor,
-		ContainerColor: podsColor,
-	}
-
-	t := "{{color .PodColor .PodName}} {{color .ContainerColor .ContainerName}} {{.Message}}"
-
-	funs := map[string]interface{}{
-		"json": func(in interface{}) (string, error) {
-			b, err := json.Marshal(in)
-			if err != nil {
-				return "", err
-			}
-			return string(b), nil
-		},
-		"parseJSON": func(text string) (map[string]interface{}, error) {
-			obj := make(map[string]interface{})
-			if err := json.Unmarshal([]byte(text), &obj); err != nil {
-				return obj, err
-			}
-			return obj, nil
-		},
-		"color": func(color color.Color, text string) string {
-			return color.SprintFunc()(text)
-		},
-	}
-	template, err := template.New("log").Funcs(funs).Parse(t)
-	if err != nil {
-		return fmt.Errorf("failed to parse log template: %w", err)
-	}
+// printContainerLog prints a color coded log message with the pod and container names
+func (cmd *podsCommand) printContainerLog(log corev1alpha1.ContainerLog) error {
+	podColor, containerColor := determineColor(log.Pod)
+	vm := cmd.printer.newLog(log.ObjectMeta, log.Payload, log)
+	vm.PodName = log.Pod
+	vm.ContainerName = log.Container
+	vm.PodColor = podColor
+	vm.ContainerColor = containerColor
+
+	if cmd.payload != nil {
+		message, ok := cmd.payload.render(log.Payload)
+		if !ok {
+			return nil
+		}
 
-	var buf bytes.Buffer
-	if err := template.Execute(&buf, vm); err != nil {
-		return fmt.Errorf("failed to execute log template: %w", err)
+		vm.Message = message
 	}
 
-	fmt.Println(buf.String())
-	return nil
+	return cmd.printer.print(vm)
 }
 
 var podsLogAdapterType = apiType{
@@ -188,6 +156,20 @@ func (h podsLogListAdapter) len() int {
 	return len(h.ContainerLogList.Items)
 }
 
+func (h podsLogListAdapter) list(ctx context.Context, client versioned.Interface, namespace string, opts metav1.ListOptions) error {
+	list, err := client.CoreV1alpha1().ContainerLogs(namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	*h.ContainerLogList = *list
+	return nil
+}
+
+func (h podsLogListAdapter) watch(ctx context.Context, client versioned.Interface, namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return client.CoreV1alpha1().ContainerLogs(namespace).Watch(ctx, opts)
+}
+
 var colorList = [][2]*color.Color{
 	{color.New(color.FgHiCyan), color.New(color.FgCyan)},
 	{color.New(color.FgHiGreen), color.New(color.FgGreen)},
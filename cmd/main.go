@@ -24,8 +24,9 @@ import (
 
 	"github.com/pyroscope-io/client/pyroscope"
 	adapterv1alpha1 "github.com/raffis/kjournal/internal/apis/core/v1alpha1"
-	"github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	corev1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
 	"github.com/raffis/kjournal/pkg/apiserver"
+	"github.com/raffis/kjournal/pkg/storage"
 	"github.com/spf13/cobra"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apiserver/pkg/registry/generic"
@@ -61,10 +62,19 @@ func main() {
 		}
 	}
 
-	withResourceAndHandler(&v1alpha1.Log{}, storageMapper(&v1alpha1.Log{}))
-	withResourceAndHandler(&v1alpha1.ContainerLog{}, storageMapper(&v1alpha1.ContainerLog{}))
+	withResourceAndHandler(&adapterv1alpha1.Log{}, storageMapper(&adapterv1alpha1.Log{}))
+	withResourceAndHandler(&adapterv1alpha1.ContainerLog{}, storageMapper(&adapterv1alpha1.ContainerLog{}))
 	withResourceAndHandler(&adapterv1alpha1.AuditEvent{}, storageMapper(&adapterv1alpha1.AuditEvent{}))
 	withResourceAndHandler(&adapterv1alpha1.Event{}, storageMapper(&adapterv1alpha1.Event{}))
+	withResourceAndHandler(&corev1alpha1.BackendStatus{}, func(scheme *k8sruntime.Scheme, getter generic.RESTOptionsGetter) (rest.Storage, error) {
+		return storage.NewBackendStatusStorage(provider), nil
+	})
+	withResourceAndHandler(&corev1alpha1.Search{}, func(scheme *k8sruntime.Scheme, getter generic.RESTOptionsGetter) (rest.Storage, error) {
+		return storage.NewSearchStorage(provider), nil
+	})
+	forGroupVersionResource(corev1alpha1.SchemeGroupVersion.WithResource("searches/results"), func(scheme *k8sruntime.Scheme, getter generic.RESTOptionsGetter) (rest.Storage, error) {
+		return storage.NewSearchResultsStorage(), nil
+	})
 
 	o := NewServerOptions(os.Stdout, os.Stderr) //, a.orderedGroupVersions...)
 	rootCmd = NewCommandStartServer(o, genericapiserver.SetupSignalHandler())
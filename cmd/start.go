@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -9,6 +10,7 @@ import (
 	"os"
 	"runtime"
 	"strconv"
+	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/spf13/cobra"
@@ -18,17 +20,28 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/wait"
 	k8sversion "k8s.io/apimachinery/pkg/version"
 	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/endpoints/openapi"
 	"k8s.io/apiserver/pkg/server"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	genericoptions "k8s.io/apiserver/pkg/server/options"
 	"k8s.io/apiserver/pkg/util/feature"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
 	"sigs.k8s.io/apiserver-runtime/pkg/builder/resource"
 
+	adapterv1alpha1 "github.com/raffis/kjournal/internal/apis/core/v1alpha1"
 	configv1alpha1 "github.com/raffis/kjournal/pkg/apis/config/v1alpha1"
 	"github.com/raffis/kjournal/pkg/apiserver"
+	"github.com/raffis/kjournal/pkg/controller"
 	"github.com/raffis/kjournal/pkg/storage"
 	_ "github.com/raffis/kjournal/pkg/storage/elasticsearch"
 )
@@ -42,7 +55,8 @@ var (
 )
 
 var (
-	provider storage.Provider
+	provider        storage.Provider
+	bindingProvider storage.BindingProvider
 )
 
 func init() {
@@ -124,6 +138,15 @@ func build() {
 type ServerOptions struct {
 	RecommendedOptions *genericoptions.RecommendedOptions
 
+	// LogSourceController enables api bindings from LogSource resources instead of the static config
+	LogSourceController bool
+	// LogSourceResyncPeriod is the interval in which LogSource status gets refreshed
+	LogSourceResyncPeriod time.Duration
+	// LeaderElect enables leader election for the LogSource status updates
+	LeaderElect bool
+	// LeaderElectionNamespace is the namespace of the leader election lease, defaults to the namespace of the service account
+	LeaderElectionNamespace string
+
 	StdOut io.Writer
 	StdErr io.Writer
 }
@@ -154,9 +177,11 @@ func NewServerOptions(out, errOut io.Writer, versions ...schema.GroupVersion) *S
 	}
 
 	o := &ServerOptions{
-		RecommendedOptions: opts,
-		StdOut:             out,
-		StdErr:             errOut,
+		RecommendedOptions:    opts,
+		LogSourceResyncPeriod: 30 * time.Second,
+		LeaderElect:           true,
+		StdOut:                out,
+		StdErr:                errOut,
 	}
 
 	return o
@@ -179,12 +204,26 @@ func NewCommandStartServer(defaults *ServerOptions, stopCh <-chan struct{}) *cob
 				return err
 			}
 
-			pr, err := storage.NewProvider(conf)
-			if err != nil {
-				return err
-			}
+			if o.LogSourceController {
+				if len(conf.Apis) > 0 {
+					klog.InfoS("logsource controller is enabled, apis from the config are ignored")
+				}
+
+				pr, err := storage.NewBindingProvider(conf)
+				if err != nil {
+					return err
+				}
 
-			provider = pr
+				provider = pr
+				bindingProvider = pr
+			} else {
+				pr, err := storage.NewProvider(conf)
+				if err != nil {
+					return err
+				}
+
+				provider = pr
+			}
 
 			if err := o.RunServer(stopCh); err != nil {
 				return err
@@ -195,6 +234,10 @@ func NewCommandStartServer(defaults *ServerOptions, stopCh <-chan struct{}) *cob
 
 	flags := cmd.Flags()
 	o.RecommendedOptions.AddFlags(flags)
+	flags.BoolVar(&o.LogSourceController, "enable-logsource-controller", o.LogSourceController, "Bind apis to LogSource resources instead of the apis defined in the apiserver config")
+	flags.DurationVar(&o.LogSourceResyncPeriod, "logsource-resync-period", o.LogSourceResyncPeriod, "Interval in which the LogSource status gets refreshed")
+	flags.BoolVar(&o.LeaderElect, "leader-elect", o.LeaderElect, "Elect a leader among the replicas which updates the LogSource status")
+	flags.StringVar(&o.LeaderElectionNamespace, "leader-election-namespace", o.LeaderElectionNamespace, "Namespace of the leader election lease, defaults to the namespace of the service account")
 	utilfeature.DefaultMutableFeatureGate.AddFlag(flags)
 
 	cmd.AddCommand(cmdMan)
@@ -262,10 +305,20 @@ func (o *ServerOptions) Config() (*apiserver.Config, error) {
 
 	serverConfig := genericapiserver.NewRecommendedConfig(apiserver.Codecs)
 
+	namer := openapi.NewDefinitionNamer(apiserver.Scheme)
+	serverConfig.OpenAPIConfig = genericapiserver.DefaultOpenAPIConfig(adapterv1alpha1.GetOpenAPIDefinitions, namer)
+	serverConfig.OpenAPIConfig.Info.Title = "kjournal"
+	serverConfig.OpenAPIConfig.Info.Version = version
+	serverConfig.OpenAPIV3Config = genericapiserver.DefaultOpenAPIV3Config(adapterv1alpha1.GetOpenAPIDefinitions, namer)
+	serverConfig.OpenAPIV3Config.Info.Title = "kjournal"
+	serverConfig.OpenAPIV3Config.Info.Version = version
+
 	if err := o.RecommendedOptions.ApplyTo(serverConfig); err != nil {
 		return nil, err
 	}
 
+	storage.Authorizer = serverConfig.Authorization.Authorizer
+
 	config := &apiserver.Config{
 		GenericConfig: serverConfig,
 		ExtraConfig:   apiserver.ExtraConfig{},
@@ -301,6 +354,10 @@ func (o ServerOptions) RunServer(stopCh <-chan struct{}) error {
 		return err
 	}
 
+	if err := server.GenericAPIServer.AddReadyzChecks(storage.ReadyzChecks(provider)...); err != nil {
+		return err
+	}
+
 	wrap := server.GenericAPIServer.Handler.FullHandlerChain
 	server.GenericAPIServer.Handler.FullHandlerChain = &httpWrap{
 		w: wrap,
@@ -313,5 +370,97 @@ func (o ServerOptions) RunServer(stopCh <-chan struct{}) error {
 		return nil
 	})
 
+	if o.LogSourceController {
+		server.GenericAPIServer.AddPostStartHookOrDie("start-logsource-controller", func(context genericapiserver.PostStartHookContext) error {
+			client, err := dynamic.NewForConfig(config.GenericConfig.ClientConfig)
+			if err != nil {
+				return err
+			}
+
+			ctrl := controller.NewLogSourceController(client, bindingProvider, o.LogSourceResyncPeriod)
+			ctx, cancel := wait.ContextForChannel(context.StopCh)
+
+			if o.LeaderElect {
+				lock, err := o.leaderElectionLock(config.GenericConfig.ClientConfig)
+				if err != nil {
+					cancel()
+					return err
+				}
+
+				go runLeaderElection(ctx, lock, ctrl)
+			} else {
+				ctrl.StartLeading()
+			}
+
+			go func() {
+				defer cancel()
+				if err := ctrl.Run(ctx); err != nil {
+					klog.ErrorS(err, "logsource controller failed")
+				}
+			}()
+
+			return nil
+		})
+	}
+
 	return server.GenericAPIServer.PrepareRun().Run(stopCh)
 }
+
+const (
+	leaderElectionLease         = "kjournal-logsource-controller"
+	leaderElectionLeaseDuration = 15 * time.Second
+	leaderElectionRenewDeadline = 10 * time.Second
+	leaderElectionRetryPeriod   = 2 * time.Second
+)
+
+// leaderElectionLock returns the lease lock of the LogSource controller
+func (o *ServerOptions) leaderElectionLock(config *rest.Config) (resourcelock.Interface, error) {
+	namespace := o.LeaderElectionNamespace
+	if namespace == "" {
+		b, err := ioutil.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect leader election namespace, use --leader-election-namespace: %w", err)
+		}
+
+		namespace = string(b)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return resourcelock.New(resourcelock.LeasesResourceLock, namespace, leaderElectionLease, client.CoreV1(), client.CoordinationV1(), resourcelock.ResourceLockConfig{
+		Identity: hostname + "_" + string(uuid.NewUUID()),
+	})
+}
+
+// runLeaderElection campaigns for the leader lease until the context is done.
+// The status updates of the LogSource controller are enabled while the lease is held.
+func runLeaderElection(ctx context.Context, lock resourcelock.Interface, ctrl *controller.LogSourceController) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			LeaseDuration:   leaderElectionLeaseDuration,
+			RenewDeadline:   leaderElectionRenewDeadline,
+			RetryPeriod:     leaderElectionRetryPeriod,
+			ReleaseOnCancel: true,
+			Name:            leaderElectionLease,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					klog.InfoS("acquired logsource controller lease", "identity", lock.Identity())
+					ctrl.StartLeading()
+				},
+				OnStoppedLeading: func() {
+					klog.InfoS("lost logsource controller lease", "identity", lock.Identity())
+					ctrl.StopLeading()
+				},
+			},
+		})
+	}, leaderElectionRetryPeriod)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
 )
 
 type AuditEventList struct {
@@ -25,9 +26,11 @@ func (in *AuditEvent) UnmarshalJSON(bs []byte) error {
 func (in *AuditEvent) New() runtime.Object {
 	return &AuditEvent{
 		AuditEvent: v1alpha1.AuditEvent{
-			TypeMeta: metav1.TypeMeta{
-				Kind:       "AuditEvent",
-				APIVersion: "core.kjournal/v1alpha1",
+			Event: auditv1.Event{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "AuditEvent",
+					APIVersion: "core.kjournal/v1alpha1",
+				},
 			},
 		},
 	}
@@ -45,7 +48,12 @@ var auditEventTableColums = []metav1.TableColumnDefinition{
 }
 
 func (in *AuditEvent) asCells() []interface{} {
-	return []interface{}{in.RequestReceivedTimestamp, in.Verb, in.ResponseStatus.Code, in.User.Username}
+	var code interface{}
+	if in.ResponseStatus != nil {
+		code = in.ResponseStatus.Code
+	}
+
+	return []interface{}{in.RequestReceivedTimestamp, in.Verb, code, in.User.Username}
 }
 
 // ConvertToTable implements the TableConvertor interface for REST.
@@ -73,12 +81,13 @@ func (in *AuditEventList) ConvertToTable(ctx context.Context, tableOptions runti
 		TypeMeta:          in.TypeMeta,
 	}
 
-	rows := make([]metav1.TableRow, 0, 1)
+	rows := make([]metav1.TableRow, 0, len(in.Items))
 
-	for _, v := range in.Items {
+	for i := range in.Items {
+		item := &in.Items[i]
 		row := metav1.TableRow{
-			Object: runtime.RawExtension{Object: &v},
-			Cells:  v.asCells(),
+			Object: runtime.RawExtension{Object: item},
+			Cells:  item.asCells(),
 		}
 		rows = append(rows, row)
 	}
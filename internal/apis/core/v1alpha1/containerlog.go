@@ -0,0 +1,84 @@
+package v1alpha1
+
+import (
+	"context"
+
+	"github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type ContainerLogList struct {
+	v1alpha1.ContainerLogList
+	Items []ContainerLog `json:"items"`
+}
+
+type ContainerLog struct {
+	v1alpha1.ContainerLog `json:",inline"`
+}
+
+func (in *ContainerLog) New() runtime.Object {
+	return &ContainerLog{
+		ContainerLog: v1alpha1.ContainerLog{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "ContainerLog",
+				APIVersion: "core.kjournal/v1alpha1",
+			},
+		},
+	}
+}
+
+func (in *ContainerLog) NewList() runtime.Object {
+	return &ContainerLogList{}
+}
+
+var containerLogTableColums = []metav1.TableColumnDefinition{
+	{Name: "TIME", Type: "string", Format: "date-time", Description: "The time the log was created."},
+	{Name: "POD", Type: "string", Description: "The pod which emitted the log."},
+	{Name: "CONTAINER", Type: "string", Description: "The container which emitted the log."},
+	{Name: "MESSAGE", Type: "string", Description: "The log message."},
+}
+
+func (in *ContainerLog) asCells() []interface{} {
+	return []interface{}{in.CreationTimestamp, in.Pod, in.Container, payloadMessage(in.Payload)}
+}
+
+// ConvertToTable implements the TableConvertor interface for REST.
+func (in *ContainerLog) ConvertToTable(ctx context.Context, tableOptions runtime.Object) (*metav1.Table, error) {
+	table := &metav1.Table{
+		ColumnDefinitions: containerLogTableColums,
+		TypeMeta:          in.TypeMeta,
+	}
+
+	rows := make([]metav1.TableRow, 0, 1)
+	row := metav1.TableRow{
+		Object: runtime.RawExtension{Object: in},
+		Cells:  in.asCells(),
+	}
+
+	rows = append(rows, row)
+	table.Rows = rows
+	return table, nil
+}
+
+// ConvertToTable implements the TableConvertor interface for REST.
+func (in *ContainerLogList) ConvertToTable(ctx context.Context, tableOptions runtime.Object) (*metav1.Table, error) {
+	table := &metav1.Table{
+		ColumnDefinitions: containerLogTableColums,
+		TypeMeta:          in.TypeMeta,
+	}
+
+	rows := make([]metav1.TableRow, 0, len(in.Items))
+
+	for i := range in.Items {
+		item := &in.Items[i]
+		row := metav1.TableRow{
+			Object: runtime.RawExtension{Object: item},
+			Cells:  item.asCells(),
+		}
+		rows = append(rows, row)
+	}
+
+	table.Rows = rows
+	return table, nil
+}
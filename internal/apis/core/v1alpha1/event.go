@@ -89,15 +89,14 @@ func (in *EventList) ConvertToTable(ctx context.Context, tableOptions runtime.Ob
 		TypeMeta:          in.TypeMeta,
 	}
 
-	rows := make([]metav1.TableRow, 0, 1)
+	rows := make([]metav1.TableRow, 0, len(in.Items))
 
-	for _, v := range in.Items {
-		item := &v
+	for i := range in.Items {
+		item := &in.Items[i]
 		row := metav1.TableRow{
 			Object: runtime.RawExtension{Object: item},
-			Cells:  v.asCells(),
+			Cells:  item.asCells(),
 		}
-		fmt.Printf("\nITEM %#v\n", item)
 		rows = append(rows, row)
 	}
 
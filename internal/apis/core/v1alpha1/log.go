@@ -0,0 +1,94 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type LogList struct {
+	v1alpha1.LogList
+	Items []Log `json:"items"`
+}
+
+type Log struct {
+	v1alpha1.Log `json:",inline"`
+}
+
+func (in *Log) New() runtime.Object {
+	return &Log{
+		Log: v1alpha1.Log{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Log",
+				APIVersion: "core.kjournal/v1alpha1",
+			},
+		},
+	}
+}
+
+func (in *Log) NewList() runtime.Object {
+	return &LogList{}
+}
+
+var logTableColums = []metav1.TableColumnDefinition{
+	{Name: "TIME", Type: "string", Format: "date-time", Description: "The time the log was created."},
+	{Name: "MESSAGE", Type: "string", Description: "The log message."},
+}
+
+func (in *Log) asCells() []interface{} {
+	return []interface{}{in.CreationTimestamp, payloadMessage(in.Payload)}
+}
+
+// ConvertToTable implements the TableConvertor interface for REST.
+func (in *Log) ConvertToTable(ctx context.Context, tableOptions runtime.Object) (*metav1.Table, error) {
+	table := &metav1.Table{
+		ColumnDefinitions: logTableColums,
+		TypeMeta:          in.TypeMeta,
+	}
+
+	rows := make([]metav1.TableRow, 0, 1)
+	row := metav1.TableRow{
+		Object: runtime.RawExtension{Object: in},
+		Cells:  in.asCells(),
+	}
+
+	rows = append(rows, row)
+	table.Rows = rows
+	return table, nil
+}
+
+// ConvertToTable implements the TableConvertor interface for REST.
+func (in *LogList) ConvertToTable(ctx context.Context, tableOptions runtime.Object) (*metav1.Table, error) {
+	table := &metav1.Table{
+		ColumnDefinitions: logTableColums,
+		TypeMeta:          in.TypeMeta,
+	}
+
+	rows := make([]metav1.TableRow, 0, len(in.Items))
+
+	for i := range in.Items {
+		item := &in.Items[i]
+		row := metav1.TableRow{
+			Object: runtime.RawExtension{Object: item},
+			Cells:  item.asCells(),
+		}
+		rows = append(rows, row)
+	}
+
+	table.Rows = rows
+	return table, nil
+}
+
+// payloadMessage returns a printable message from a log payload.
+// A payload which is a json string gets unquoted, any other payload is returned as compact json.
+func payloadMessage(payload json.RawMessage) string {
+	var msg string
+	if err := json.Unmarshal(payload, &msg); err == nil {
+		return msg
+	}
+
+	return string(payload)
+}
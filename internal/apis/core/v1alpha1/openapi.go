@@ -0,0 +1,53 @@
+package v1alpha1
+
+import (
+	"reflect"
+
+	"k8s.io/kube-openapi/pkg/common"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+
+	"github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	"github.com/raffis/kjournal/pkg/generated/openapi"
+)
+
+// GetOpenAPIDefinitions returns the openapi definitions for the core.kjournal api.
+// The adapters are published with the definitions of the api types they wrap.
+func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
+	defs := openapi.GetOpenAPIDefinitions(ref)
+
+	// The payload is an arbitrary json document, openapi-gen can't derive that from json.RawMessage
+	for _, obj := range []interface{}{v1alpha1.ContainerLog{}, v1alpha1.Log{}} {
+		defs[typeName(obj)].Schema.Properties["payload"] = spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "Payload holds the log message which can be any json value.",
+			},
+			VendorExtensible: spec.VendorExtensible{
+				Extensions: spec.Extensions{
+					"x-kubernetes-preserve-unknown-fields": true,
+				},
+			},
+		}
+	}
+
+	adapters := [][2]interface{}{
+		{ContainerLog{}, v1alpha1.ContainerLog{}},
+		{ContainerLogList{}, v1alpha1.ContainerLogList{}},
+		{Log{}, v1alpha1.Log{}},
+		{LogList{}, v1alpha1.LogList{}},
+		{Event{}, v1alpha1.Event{}},
+		{EventList{}, v1alpha1.EventList{}},
+		{AuditEvent{}, v1alpha1.AuditEvent{}},
+		{AuditEventList{}, v1alpha1.AuditEventList{}},
+	}
+
+	for _, adapter := range adapters {
+		defs[typeName(adapter[0])] = defs[typeName(adapter[1])]
+	}
+
+	return defs
+}
+
+func typeName(obj interface{}) string {
+	t := reflect.TypeOf(obj)
+	return t.PkgPath() + "." + t.Name()
+}
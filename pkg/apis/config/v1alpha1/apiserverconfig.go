@@ -8,8 +8,45 @@ import (
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 type APIServerConfig struct {
 	metav1.TypeMeta `json:",inline,omitempty"`
-	Backend         Backend `json:"backend,omitempty"`
-	Apis            []API   `json:"apis,omitempty"`
+	Backend         Backend    `json:"backend,omitempty"`
+	Apis            []API      `json:"apis,omitempty"`
+	AccessLog       *AccessLog `json:"accessLog,omitempty"`
+
+	// ClusterName is the name of the kubernetes cluster the apiserver is serving.
+	// It is not detected and must match the value the log shippers of this cluster write to the cluster field of the apis.
+	// Apis with a cluster field are scoped to this cluster unless requests select other clusters.
+	ClusterName string `json:"clusterName,omitempty"`
+}
+
+// AccessLogSink is the destination of the access log
+type AccessLogSink string
+
+const (
+	// AccessLogSinkStdout writes the access log to stdout
+	AccessLogSinkStdout AccessLogSink = "stdout"
+	// AccessLogSinkFile appends the access log to a file
+	AccessLogSinkFile AccessLogSink = "file"
+	// AccessLogSinkWebhook sends each access log entry to a webhook
+	AccessLogSinkWebhook AccessLogSink = "webhook"
+)
+
+// AccessLog records who queried which logs.
+// An entry is written for every list and watch request.
+type AccessLog struct {
+	// Sink is either stdout, file or webhook. Defaults to stdout.
+	Sink AccessLogSink `json:"sink,omitempty"`
+
+	// Path is the file the access log is appended to if the file sink is used
+	Path string `json:"path,omitempty"`
+
+	// Webhook receives the entries as json if the webhook sink is used
+	Webhook AccessLogWebhook `json:"webhook,omitempty"`
+}
+
+type AccessLogWebhook struct {
+	URL     string          `json:"url,omitempty"`
+	TLS     TLS             `json:"tls,omitempty"`
+	Timeout metav1.Duration `json:"timeout,omitempty"`
 }
 
 type Backend struct {
@@ -23,8 +60,52 @@ type TLS struct {
 }
 
 type BackendElasticsearch struct {
-	URL []string `json:"url,omitempty"`
-	TLS TLS      `json:"tls,omitempty"`
+	URL        []string   `json:"url,omitempty"`
+	TLS        TLS        `json:"tls,omitempty"`
+	Tracing    Tracing    `json:"tracing,omitempty"`
+	Connection Connection `json:"connection,omitempty"`
+}
+
+// Connection configures the connection pool and the request handling of the backend client
+type Connection struct {
+	// MaxConnsPerHost limits the number of connections per node. Defaults to no limit.
+	MaxConnsPerHost int `json:"maxConnsPerHost,omitempty"`
+
+	// MaxIdleConnsPerHost is the number of idle connections kept open per node. Defaults to 100.
+	MaxIdleConnsPerHost int `json:"maxIdleConnsPerHost,omitempty"`
+
+	// IdleConnTimeout closes connections which have been idle for this duration. Defaults to 90s.
+	IdleConnTimeout metav1.Duration `json:"idleConnTimeout,omitempty"`
+
+	// ConnectTimeout is the maximum duration to establish a connection including the TLS handshake. Defaults to 10s.
+	ConnectTimeout metav1.Duration `json:"connectTimeout,omitempty"`
+
+	// RequestTimeout is the maximum duration to wait for the response of a request. Defaults to no timeout.
+	RequestTimeout metav1.Duration `json:"requestTimeout,omitempty"`
+
+	// SearchTimeout is passed to search requests, the backend returns partial results once it is exceeded
+	SearchTimeout metav1.Duration `json:"searchTimeout,omitempty"`
+
+	// Compression enables gzip compression of request bodies. Responses are always requested with gzip compression.
+	Compression bool `json:"compression,omitempty"`
+
+	// MaxRetries is the number of retries on 429, 500, 502, 503 and 504 responses. Defaults to 3, a negative number disables retries.
+	MaxRetries int `json:"maxRetries,omitempty"`
+
+	// RetryBackoff is the backoff before the first retry, it doubles with each retry. Defaults to 100ms.
+	RetryBackoff metav1.Duration `json:"retryBackoff,omitempty"`
+
+	// DiscoverNodesOnStart sniffs the nodes of the cluster once the client is created
+	DiscoverNodesOnStart bool `json:"discoverNodesOnStart,omitempty"`
+
+	// DiscoverNodesInterval periodically sniffs the nodes of the cluster. Defaults to disabled.
+	DiscoverNodesInterval metav1.Duration `json:"discoverNodesInterval,omitempty"`
+}
+
+// Tracing configures the spans created for backend requests
+type Tracing struct {
+	// RedactQuery omits the translated backend query from the spans and the access log
+	RedactQuery bool `json:"redactQuery,omitempty"`
 }
 
 type API struct {
@@ -34,6 +115,100 @@ type API struct {
 	Filter           string              `json:"filter,omitempty"`
 	Backend          ApiBackend          `json:"backend,omitempty"`
 	DefaultTimeRange string              `json:"defaultTimeRange,omitempty"`
+	Columns          []Column            `json:"columns,omitempty"`
+	Limits           *Limits             `json:"limits,omitempty"`
+	Redactions       []Redaction         `json:"redactions,omitempty"`
+
+	// AsyncSearchThreshold is the time range above which list requests are submitted as search instead.
+	// The request returns an empty list and a warning naming the search, its results are retrieved from the searches resource.
+	AsyncSearchThreshold metav1.Duration `json:"asyncSearchThreshold,omitempty"`
+
+	// ClusterField is the field of the stored documents which holds the name of the kubernetes cluster.
+	// It is selected using the cluster field selector.
+	ClusterField string `json:"clusterField,omitempty"`
+
+	// DefaultCluster is the cluster requests are scoped to if they do not select clusters.
+	// Defaults to the clusterName of the apiserver.
+	DefaultCluster string `json:"defaultCluster,omitempty"`
+}
+
+// Limits guards the storage backend from expensive requests.
+// The limits are enforced per requesting user.
+type Limits struct {
+	LimitSpec `json:",inline"`
+
+	// Overrides replace the limits for specific users or groups. The first matching override is applied.
+	Overrides []LimitOverride `json:"overrides,omitempty"`
+}
+
+type LimitSpec struct {
+	// MaxTimeRange is the maximum time range a single request may query
+	MaxTimeRange metav1.Duration `json:"maxTimeRange,omitempty"`
+
+	// MaxLimit is the maximum number of objects a single list request may return.
+	// It is also used as limit for requests which do not specify one.
+	MaxLimit int64 `json:"maxLimit,omitempty"`
+
+	// MaxConcurrentWatches is the maximum number of concurrent watches
+	MaxConcurrentWatches int32 `json:"maxConcurrentWatches,omitempty"`
+
+	// QPS is the maximum number of list and watch requests per second
+	QPS int32 `json:"qps,omitempty"`
+
+	// Burst is the maximum burst of requests on top of QPS
+	Burst int32 `json:"burst,omitempty"`
+}
+
+type LimitOverride struct {
+	Users     []string `json:"users,omitempty"`
+	Groups    []string `json:"groups,omitempty"`
+	LimitSpec `json:",inline"`
+}
+
+// RedactionAction defines how redacted values are replaced
+type RedactionAction string
+
+const (
+	// RedactionActionDrop removes the field or the pattern match
+	RedactionActionDrop RedactionAction = "drop"
+	// RedactionActionMask replaces the value or the pattern match with a fixed mask
+	RedactionActionMask RedactionAction = "mask"
+	// RedactionActionHash replaces the value or the pattern match with its hmac-sha256 hash
+	RedactionActionHash RedactionAction = "hash"
+)
+
+// Redaction hides sensitive data from requesters.
+// Requesters which are allowed to get the unredacted subresource (e.g. auditevents/unredacted) are never affected.
+type Redaction struct {
+	// Fields are the dotted paths of the redacted fields, e.g. requestObject.
+	// If patterns are defined only the matches within these fields are redacted.
+	Fields []string `json:"fields,omitempty"`
+
+	// Patterns are regular expressions matched against string values, e.g. tokens or emails.
+	// If no fields are defined the entire object is searched.
+	Patterns []string `json:"patterns,omitempty"`
+
+	// Action is either drop, mask or hash. Defaults to mask.
+	Action RedactionAction `json:"action,omitempty"`
+
+	// HashKeyFile is the file holding the secret key of the hmac-sha256 hash, it is required for the hash action.
+	// A keyed hash prevents to recover short or predictable values by hashing candidates.
+	HashKeyFile string `json:"hashKeyFile,omitempty"`
+
+	// Groups restricts the redaction to members of these groups. By default it applies to everyone.
+	Groups []string `json:"groups,omitempty"`
+
+	// ExemptGroups are groups whose members are not affected
+	ExemptGroups []string `json:"exemptGroups,omitempty"`
+}
+
+// Column is an additional column in the server side table output
+type Column struct {
+	// Name is the column header
+	Name string `json:"name,omitempty"`
+
+	// Path is the dotted path of the value within the object, e.g. payload.level
+	Path string `json:"path,omitempty"`
 }
 
 type ApiBackend struct {
@@ -41,8 +216,54 @@ type ApiBackend struct {
 }
 
 type ApiBackendElasticsearch struct {
-	Index           string          `json:"index,omitempty"`
-	RefreshRate     metav1.Duration `json:"refreshRate,omitempty"`
-	TimestampFields []string        `json:"timestampFields,omitempty"`
-	BulkSize        int64           `json:"bulkSize,omitempty"`
+	// Index is the index pattern which is queried.
+	// For namespaced resources it may contain the {namespace} placeholder to route queries to per namespace indices.
+	// A wildcard following the placeholder must be delimited by a character which is not valid within namespace names (e.g. logs-{namespace}.*).
+	// Date templates like {yyyy.MM.dd} are resolved to the indices overlapping the requested time range.
+	Index string `json:"index,omitempty"`
+
+	// NamespaceIndex maps namespaces to the index pattern which holds their documents.
+	// Namespaces which are not mapped are routed to Index.
+	NamespaceIndex  map[string]string `json:"namespaceIndex,omitempty"`
+	RefreshRate     metav1.Duration   `json:"refreshRate,omitempty"`
+	TimestampFields []string          `json:"timestampFields,omitempty"`
+	BulkSize        int64             `json:"bulkSize,omitempty"`
+
+	// Cache caches the results of queries whose time range is entirely in the past
+	Cache *QueryCache `json:"cache,omitempty"`
+
+	// DataStream is the data stream which is queried instead of Index.
+	// Backing indices which were rolled over before the requested time range are not searched.
+	// It is routed by namespace, date templates and clusters the same way as Index.
+	DataStream string `json:"dataStream,omitempty"`
+
+	// AsyncSearch routes searches which touch backing indices of a data stream on the given data tiers through the async search api
+	AsyncSearch *AsyncSearch `json:"asyncSearch,omitempty"`
+
+	// Clusters are the aliases of the remote clusters which are searched using cross cluster search.
+	// The index patterns are searched on each of the clusters, the alias local refers to the local cluster.
+	Clusters []string `json:"clusters,omitempty"`
+}
+
+// AsyncSearch configures searches through the elasticsearch async search api
+type AsyncSearch struct {
+	// Tiers are the data tiers which are searched asynchronously. Defaults to cold and frozen.
+	Tiers []string `json:"tiers,omitempty"`
+
+	// WaitForCompletionTimeout is the duration elasticsearch blocks a submit or poll request
+	// before the search is polled again. Defaults to 5s.
+	WaitForCompletionTimeout metav1.Duration `json:"waitForCompletionTimeout,omitempty"`
+
+	// KeepAlive is the duration elasticsearch keeps the results of a search. Defaults to 5m.
+	KeepAlive metav1.Duration `json:"keepAlive,omitempty"`
+}
+
+// QueryCache is an in memory cache for query results.
+// Only results of queries with an upper time bound in the past are cached as these do not change anymore.
+type QueryCache struct {
+	// Size is the maximum number of cached query results
+	Size int `json:"size,omitempty"`
+
+	// TTL is the duration a query result is cached. Defaults to 5m.
+	TTL metav1.Duration `json:"ttl,omitempty"`
 }
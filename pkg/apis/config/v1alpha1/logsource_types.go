@@ -0,0 +1,63 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// LogSourceBoundCondition reports whether the LogSource is the active binding for its resource
+	LogSourceBoundCondition = "Bound"
+
+	// LogSourceBackendReachableCondition reports whether the storage backend responds
+	LogSourceBackendReachableCondition = "BackendReachable"
+
+	// LogSourceStorageResolvedCondition reports whether the storage of the LogSource resolves within the backend,
+	// e.g. whether the index pattern matches any indices
+	LogSourceStorageResolvedCondition = "StorageResolved"
+
+	// LogSourceQuerySucceededCondition reports whether the last query against the backend succeeded
+	LogSourceQuerySucceededCondition = "QuerySucceeded"
+)
+
+// LogSource binds a kjournal api to the storage backend.
+// It is the in-cluster alternative to the apis defined in the static APIServerConfig.
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Resource",type="string",JSONPath=".spec.resource"
+// +kubebuilder:printcolumn:name="Bound",type="string",JSONPath=".status.conditions[?(@.type==\"Bound\")].status"
+// +kubebuilder:printcolumn:name="Reachable",type="string",JSONPath=".status.conditions[?(@.type==\"BackendReachable\")].status"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type LogSource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   API             `json:"spec,omitempty"`
+	Status LogSourceStatus `json:"status,omitempty"`
+}
+
+type LogSourceStatus struct {
+	// ObservedGeneration is the last generation reconciled by the apiserver
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastQueryError holds the error message of the last failed backend query
+	LastQueryError string `json:"lastQueryError,omitempty"`
+
+	// LastQueryErrorTime is the time the last failed backend query happened
+	LastQueryErrorTime *metav1.Time `json:"lastQueryErrorTime,omitempty"`
+
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// LogSourceList
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type LogSourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []LogSource `json:"items"`
+}
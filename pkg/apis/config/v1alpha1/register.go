@@ -22,17 +22,21 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+// SchemeGroupVersion is group version used to register these objects
+var SchemeGroupVersion = schema.GroupVersion{Group: "config.kjournal", Version: "v1alpha1"}
+
+// Resource takes an unqualified resource and returns a Group qualified GroupResource
+func Resource(resource string) schema.GroupResource {
+	return SchemeGroupVersion.WithResource(resource).GroupResource()
+}
+
 var AddToScheme = func(scheme *runtime.Scheme) error {
-	metav1.AddToGroupVersion(scheme, schema.GroupVersion{
-		Group:   "config.kjournal",
-		Version: "v1alpha1",
-	})
+	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	// +kubebuilder:scaffold:install
 
-	scheme.AddKnownTypes(schema.GroupVersion{
-		Group:   "config.kjournal",
-		Version: "v1alpha1",
-	}, &APIServerConfig{})
+	scheme.AddKnownTypes(SchemeGroupVersion, &APIServerConfig{})
+
+	scheme.AddKnownTypes(SchemeGroupVersion, &LogSource{}, &LogSourceList{})
 
 	return nil
 }
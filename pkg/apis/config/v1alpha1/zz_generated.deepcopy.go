@@ -22,9 +22,43 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessLog) DeepCopyInto(out *AccessLog) {
+	*out = *in
+	out.Webhook = in.Webhook
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessLog.
+func (in *AccessLog) DeepCopy() *AccessLog {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessLog)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessLogWebhook) DeepCopyInto(out *AccessLogWebhook) {
+	*out = *in
+	out.TLS = in.TLS
+	out.Timeout = in.Timeout
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessLogWebhook.
+func (in *AccessLogWebhook) DeepCopy() *AccessLogWebhook {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessLogWebhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *API) DeepCopyInto(out *API) {
 	*out = *in
@@ -49,6 +83,24 @@ func (in *API) DeepCopyInto(out *API) {
 		copy(*out, *in)
 	}
 	in.Backend.DeepCopyInto(&out.Backend)
+	if in.Columns != nil {
+		in, out := &in.Columns, &out.Columns
+		*out = make([]Column, len(*in))
+		copy(*out, *in)
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = new(Limits)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Redactions != nil {
+		in, out := &in.Redactions, &out.Redactions
+		*out = make([]Redaction, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.AsyncSearchThreshold = in.AsyncSearchThreshold
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new API.
@@ -73,6 +125,11 @@ func (in *APIServerConfig) DeepCopyInto(out *APIServerConfig) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.AccessLog != nil {
+		in, out := &in.AccessLog, &out.AccessLog
+		*out = new(AccessLog)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APIServerConfig.
@@ -112,12 +169,34 @@ func (in *ApiBackend) DeepCopy() *ApiBackend {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApiBackendElasticsearch) DeepCopyInto(out *ApiBackendElasticsearch) {
 	*out = *in
+	if in.NamespaceIndex != nil {
+		in, out := &in.NamespaceIndex, &out.NamespaceIndex
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	out.RefreshRate = in.RefreshRate
 	if in.TimestampFields != nil {
 		in, out := &in.TimestampFields, &out.TimestampFields
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Cache != nil {
+		in, out := &in.Cache, &out.Cache
+		*out = new(QueryCache)
+		**out = **in
+	}
+	if in.AsyncSearch != nil {
+		in, out := &in.AsyncSearch, &out.AsyncSearch
+		*out = new(AsyncSearch)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApiBackendElasticsearch.
@@ -130,6 +209,28 @@ func (in *ApiBackendElasticsearch) DeepCopy() *ApiBackendElasticsearch {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AsyncSearch) DeepCopyInto(out *AsyncSearch) {
+	*out = *in
+	if in.Tiers != nil {
+		in, out := &in.Tiers, &out.Tiers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.WaitForCompletionTimeout = in.WaitForCompletionTimeout
+	out.KeepAlive = in.KeepAlive
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AsyncSearch.
+func (in *AsyncSearch) DeepCopy() *AsyncSearch {
+	if in == nil {
+		return nil
+	}
+	out := new(AsyncSearch)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Backend) DeepCopyInto(out *Backend) {
 	*out = *in
@@ -159,6 +260,8 @@ func (in *BackendElasticsearch) DeepCopyInto(out *BackendElasticsearch) {
 		copy(*out, *in)
 	}
 	out.TLS = in.TLS
+	out.Tracing = in.Tracing
+	out.Connection = in.Connection
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendElasticsearch.
@@ -171,6 +274,192 @@ func (in *BackendElasticsearch) DeepCopy() *BackendElasticsearch {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Column) DeepCopyInto(out *Column) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Column.
+func (in *Column) DeepCopy() *Column {
+	if in == nil {
+		return nil
+	}
+	out := new(Column)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Connection) DeepCopyInto(out *Connection) {
+	*out = *in
+	out.IdleConnTimeout = in.IdleConnTimeout
+	out.ConnectTimeout = in.ConnectTimeout
+	out.RequestTimeout = in.RequestTimeout
+	out.SearchTimeout = in.SearchTimeout
+	out.RetryBackoff = in.RetryBackoff
+	out.DiscoverNodesInterval = in.DiscoverNodesInterval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Connection.
+func (in *Connection) DeepCopy() *Connection {
+	if in == nil {
+		return nil
+	}
+	out := new(Connection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LimitOverride) DeepCopyInto(out *LimitOverride) {
+	*out = *in
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.LimitSpec = in.LimitSpec
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LimitOverride.
+func (in *LimitOverride) DeepCopy() *LimitOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(LimitOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LimitSpec) DeepCopyInto(out *LimitSpec) {
+	*out = *in
+	out.MaxTimeRange = in.MaxTimeRange
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LimitSpec.
+func (in *LimitSpec) DeepCopy() *LimitSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LimitSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Limits) DeepCopyInto(out *Limits) {
+	*out = *in
+	out.LimitSpec = in.LimitSpec
+	if in.Overrides != nil {
+		in, out := &in.Overrides, &out.Overrides
+		*out = make([]LimitOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Limits.
+func (in *Limits) DeepCopy() *Limits {
+	if in == nil {
+		return nil
+	}
+	out := new(Limits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogSource) DeepCopyInto(out *LogSource) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogSource.
+func (in *LogSource) DeepCopy() *LogSource {
+	if in == nil {
+		return nil
+	}
+	out := new(LogSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LogSource) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogSourceList) DeepCopyInto(out *LogSourceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LogSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogSourceList.
+func (in *LogSourceList) DeepCopy() *LogSourceList {
+	if in == nil {
+		return nil
+	}
+	out := new(LogSourceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LogSourceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogSourceStatus) DeepCopyInto(out *LogSourceStatus) {
+	*out = *in
+	if in.LastQueryErrorTime != nil {
+		in, out := &in.LastQueryErrorTime, &out.LastQueryErrorTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogSourceStatus.
+func (in *LogSourceStatus) DeepCopy() *LogSourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LogSourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TLS) DeepCopyInto(out *TLS) {
 	*out = *in
@@ -185,3 +474,69 @@ func (in *TLS) DeepCopy() *TLS {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryCache) DeepCopyInto(out *QueryCache) {
+	*out = *in
+	out.TTL = in.TTL
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryCache.
+func (in *QueryCache) DeepCopy() *QueryCache {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryCache)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Redaction) DeepCopyInto(out *Redaction) {
+	*out = *in
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Patterns != nil {
+		in, out := &in.Patterns, &out.Patterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExemptGroups != nil {
+		in, out := &in.ExemptGroups, &out.ExemptGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Redaction.
+func (in *Redaction) DeepCopy() *Redaction {
+	if in == nil {
+		return nil
+	}
+	out := new(Redaction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Tracing) DeepCopyInto(out *Tracing) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Tracing.
+func (in *Tracing) DeepCopy() *Tracing {
+	if in == nil {
+		return nil
+	}
+	out := new(Tracing)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -20,11 +20,13 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	auditv1 "k8s.io/apiserver/pkg/apis/audit"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
 	"sigs.k8s.io/apiserver-runtime/pkg/builder/resource"
 )
 
 // +genclient
+// +genclient:nonNamespaced
+// +genclient:onlyVerbs=list,watch
 
 // AuditEvent
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -32,8 +34,8 @@ type AuditEvent struct {
 	// ObjectMeta is only included to fullfil metav1.Object interface,
 	// it will be omitted from any json de and encoding. It is required for storage.ConvertToTable()
 	metav1.ObjectMeta `json:"-"`
-	metav1.TypeMeta   `json:",inline"`
 
+	// Event includes the TypeMeta of the AuditEvent
 	auditv1.Event `json:",inline"`
 }
 
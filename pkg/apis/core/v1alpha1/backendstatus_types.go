@@ -0,0 +1,117 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/apiserver-runtime/pkg/builder/resource"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:onlyVerbs=get,list
+
+// BackendStatus reports the health of a storage backend.
+// The status is probed whenever the resource is read.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type BackendStatus struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status BackendStatusStatus `json:"status,omitempty"`
+}
+
+type BackendStatusStatus struct {
+	// Reachable is true if the storage backend responds
+	Reachable bool `json:"reachable"`
+
+	// Latency is the duration the storage backend took to respond
+	Latency metav1.Duration `json:"latency,omitempty"`
+
+	// LastProbeTime is the time the storage backend was probed
+	LastProbeTime metav1.Time `json:"lastProbeTime,omitempty"`
+
+	// Error is the error of the probe if the backend is not reachable
+	Error string `json:"error,omitempty"`
+
+	// APIs reports whether the storage of each api binding resolves, e.g. whether an index pattern matches any indices
+	APIs []APIStatus `json:"apis,omitempty"`
+}
+
+type APIStatus struct {
+	// Resource is the resource the api binding serves
+	Resource string `json:"resource"`
+
+	// Resolved is true if the storage of the api binding resolves within the backend
+	Resolved bool `json:"resolved"`
+
+	// Error is the reason the storage of the api binding does not resolve
+	Error string `json:"error,omitempty"`
+}
+
+// BackendStatusList
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type BackendStatusList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []BackendStatus `json:"items"`
+}
+
+var _ resource.Object = &BackendStatus{}
+
+func (in *BackendStatus) GetObjectMeta() *metav1.ObjectMeta {
+	return &in.ObjectMeta
+}
+
+func (in *BackendStatus) NamespaceScoped() bool {
+	return false
+}
+
+func (in *BackendStatus) New() runtime.Object {
+	return &BackendStatus{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "BackendStatus",
+			APIVersion: "core.kjournal/v1alpha1",
+		},
+	}
+}
+
+func (in *BackendStatus) NewList() runtime.Object {
+	return &BackendStatusList{}
+}
+
+func (in *BackendStatus) GetGroupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    "core.kjournal",
+		Version:  "v1alpha1",
+		Resource: "backendstatuses",
+	}
+}
+
+func (in *BackendStatus) IsStorageVersion() bool {
+	return true
+}
+
+var _ resource.ObjectList = &BackendStatusList{}
+
+func (in *BackendStatusList) GetListMeta() *metav1.ListMeta {
+	return &in.ListMeta
+}
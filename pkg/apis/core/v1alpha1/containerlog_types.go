@@ -26,10 +26,10 @@ import (
 )
 
 // +genclient
+// +genclient:onlyVerbs=list,watch
 
 // ContainerLog
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
-
 type ContainerLog struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
@@ -26,6 +26,7 @@ import (
 )
 
 // +genclient
+// +genclient:onlyVerbs=list,watch
 
 // Event
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
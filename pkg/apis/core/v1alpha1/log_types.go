@@ -26,6 +26,8 @@ import (
 )
 
 // +genclient
+// +genclient:nonNamespaced
+// +genclient:onlyVerbs=list,watch
 
 // Log
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -22,32 +22,29 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+// SchemeGroupVersion is group version used to register these objects
+var SchemeGroupVersion = schema.GroupVersion{Group: "core.kjournal", Version: "v1alpha1"}
+
+// Resource takes an unqualified resource and returns a Group qualified GroupResource
+func Resource(resource string) schema.GroupResource {
+	return SchemeGroupVersion.WithResource(resource).GroupResource()
+}
+
 var AddToScheme = func(scheme *runtime.Scheme) error {
-	metav1.AddToGroupVersion(scheme, schema.GroupVersion{
-		Group:   "core.kjournal",
-		Version: "v1alpha1",
-	})
+	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	// +kubebuilder:scaffold:install
 
-	scheme.AddKnownTypes(schema.GroupVersion{
-		Group:   "core.kjournal",
-		Version: "v1alpha1",
-	}, &ContainerLog{}, &ContainerLogList{})
-
-	scheme.AddKnownTypes(schema.GroupVersion{
-		Group:   "core.kjournal",
-		Version: "v1alpha1",
-	}, &Event{}, &EventList{})
-
-	scheme.AddKnownTypes(schema.GroupVersion{
-		Group:   "core.kjournal",
-		Version: "v1alpha1",
-	}, &AuditEvent{}, &AuditEventList{})
-
-	scheme.AddKnownTypes(schema.GroupVersion{
-		Group:   "core.kjournal",
-		Version: "v1alpha1",
-	}, &Log{}, &LogList{})
+	scheme.AddKnownTypes(SchemeGroupVersion, &ContainerLog{}, &ContainerLogList{})
+
+	scheme.AddKnownTypes(SchemeGroupVersion, &Event{}, &EventList{})
+
+	scheme.AddKnownTypes(SchemeGroupVersion, &AuditEvent{}, &AuditEventList{})
+
+	scheme.AddKnownTypes(SchemeGroupVersion, &Log{}, &LogList{})
+
+	scheme.AddKnownTypes(SchemeGroupVersion, &BackendStatus{}, &BackendStatusList{})
+
+	scheme.AddKnownTypes(SchemeGroupVersion, &Search{}, &SearchList{}, &SearchResultsOptions{})
 
 	return nil
 }
@@ -0,0 +1,168 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"net/url"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/apiserver-runtime/pkg/builder/resource"
+)
+
+// SearchPhase is the state of a search
+type SearchPhase string
+
+const (
+	// SearchRunning means the storage backend is still collecting results
+	SearchRunning SearchPhase = "Running"
+	// SearchCompleted means the results can be retrieved from the results subresource
+	SearchCompleted SearchPhase = "Completed"
+	// SearchFailed means the search failed within the storage backend
+	SearchFailed SearchPhase = "Failed"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:onlyVerbs=create,get,list,delete
+
+// Search is an asynchronous query on a kjournal resource for long running historical queries.
+// The results are retrieved page by page from the results subresource once the search completed.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type Search struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SearchSpec   `json:"spec,omitempty"`
+	Status SearchStatus `json:"status,omitempty"`
+}
+
+type SearchSpec struct {
+	// Resource is the searched resource, e.g. auditevents
+	Resource string `json:"resource"`
+
+	// Namespace restricts the search on a namespaced resource to a namespace
+	Namespace string `json:"namespace,omitempty"`
+
+	// FieldSelector selects the objects the same way as the field selector of a list request
+	FieldSelector string `json:"fieldSelector,omitempty"`
+
+	// Limit is the maximum number of results
+	Limit int64 `json:"limit,omitempty"`
+
+	// User is the user who submitted the search, it is set by the server.
+	// Searches are only visible to the user who submitted them.
+	User string `json:"user,omitempty"`
+}
+
+type SearchStatus struct {
+	// Phase is either Running, Completed or Failed
+	Phase SearchPhase `json:"phase,omitempty"`
+
+	// StartTime is the time the search was submitted
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time the search was observed as completed
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// ExpirationTime is the time the storage backend discards the results
+	ExpirationTime *metav1.Time `json:"expirationTime,omitempty"`
+
+	// Error is the reason the search failed
+	Error string `json:"error,omitempty"`
+}
+
+// SearchList
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type SearchList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Search `json:"items"`
+}
+
+// SearchResultsOptions are the query parameters of the results subresource
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type SearchResultsOptions struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Limit is the maximum number of results returned in a page
+	Limit int64 `json:"limit,omitempty"`
+
+	// Continue is the continue token of the previous page
+	Continue string `json:"continue,omitempty"`
+}
+
+var _ resource.QueryParameterObject = &SearchResultsOptions{}
+
+func (in *SearchResultsOptions) ConvertFromUrlValues(values *url.Values) error {
+	if limit := values.Get("limit"); limit != "" {
+		v, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		in.Limit = v
+	}
+
+	in.Continue = values.Get("continue")
+	return nil
+}
+
+var _ resource.Object = &Search{}
+
+func (in *Search) GetObjectMeta() *metav1.ObjectMeta {
+	return &in.ObjectMeta
+}
+
+func (in *Search) NamespaceScoped() bool {
+	return false
+}
+
+func (in *Search) New() runtime.Object {
+	return &Search{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Search",
+			APIVersion: "core.kjournal/v1alpha1",
+		},
+	}
+}
+
+func (in *Search) NewList() runtime.Object {
+	return &SearchList{}
+}
+
+func (in *Search) GetGroupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    "core.kjournal",
+		Version:  "v1alpha1",
+		Resource: "searches",
+	}
+}
+
+func (in *Search) IsStorageVersion() bool {
+	return true
+}
+
+var _ resource.ObjectList = &SearchList{}
+
+func (in *SearchList) GetListMeta() *metav1.ListMeta {
+	return &in.ListMeta
+}
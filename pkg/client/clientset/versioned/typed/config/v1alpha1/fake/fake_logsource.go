@@ -0,0 +1,132 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/raffis/kjournal/pkg/apis/config/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeLogSources implements LogSourceInterface
+type FakeLogSources struct {
+	Fake *FakeConfigV1alpha1
+}
+
+var logsourcesResource = schema.GroupVersionResource{Group: "config.kjournal", Version: "v1alpha1", Resource: "logsources"}
+
+var logsourcesKind = schema.GroupVersionKind{Group: "config.kjournal", Version: "v1alpha1", Kind: "LogSource"}
+
+// Get takes name of the logSource, and returns the corresponding logSource object, and an error if there is any.
+func (c *FakeLogSources) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.LogSource, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(logsourcesResource, name), &v1alpha1.LogSource{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.LogSource), err
+}
+
+// List takes label and field selectors, and returns the list of LogSources that match those selectors.
+func (c *FakeLogSources) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.LogSourceList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(logsourcesResource, logsourcesKind, opts), &v1alpha1.LogSourceList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.LogSourceList{ListMeta: obj.(*v1alpha1.LogSourceList).ListMeta}
+	for _, item := range obj.(*v1alpha1.LogSourceList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested logSources.
+func (c *FakeLogSources) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(logsourcesResource, opts))
+}
+
+// Create takes the representation of a logSource and creates it.  Returns the server's representation of the logSource, and an error, if there is any.
+func (c *FakeLogSources) Create(ctx context.Context, logSource *v1alpha1.LogSource, opts v1.CreateOptions) (result *v1alpha1.LogSource, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(logsourcesResource, logSource), &v1alpha1.LogSource{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.LogSource), err
+}
+
+// Update takes the representation of a logSource and updates it. Returns the server's representation of the logSource, and an error, if there is any.
+func (c *FakeLogSources) Update(ctx context.Context, logSource *v1alpha1.LogSource, opts v1.UpdateOptions) (result *v1alpha1.LogSource, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(logsourcesResource, logSource), &v1alpha1.LogSource{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.LogSource), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeLogSources) UpdateStatus(ctx context.Context, logSource *v1alpha1.LogSource, opts v1.UpdateOptions) (*v1alpha1.LogSource, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateSubresourceAction(logsourcesResource, "status", logSource), &v1alpha1.LogSource{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.LogSource), err
+}
+
+// Delete takes name of the logSource and deletes it. Returns an error if one occurs.
+func (c *FakeLogSources) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteActionWithOptions(logsourcesResource, name, opts), &v1alpha1.LogSource{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeLogSources) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(logsourcesResource, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.LogSourceList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched logSource.
+func (c *FakeLogSources) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.LogSource, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(logsourcesResource, name, pt, data, subresources...), &v1alpha1.LogSource{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.LogSource), err
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	scheme "github.com/raffis/kjournal/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// AuditEventsGetter has a method to return a AuditEventInterface.
+// A group's client should implement this interface.
+type AuditEventsGetter interface {
+	AuditEvents() AuditEventInterface
+}
+
+// AuditEventInterface has methods to work with AuditEvent resources.
+type AuditEventInterface interface {
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.AuditEventList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	AuditEventExpansion
+}
+
+// auditEvents implements AuditEventInterface
+type auditEvents struct {
+	client rest.Interface
+}
+
+// newAuditEvents returns a AuditEvents
+func newAuditEvents(c *CoreV1alpha1Client) *auditEvents {
+	return &auditEvents{
+		client: c.RESTClient(),
+	}
+}
+
+// List takes label and field selectors, and returns the list of AuditEvents that match those selectors.
+func (c *auditEvents) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.AuditEventList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.AuditEventList{}
+	err = c.client.Get().
+		Resource("auditevents").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested auditEvents.
+func (c *auditEvents) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("auditevents").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	scheme "github.com/raffis/kjournal/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	rest "k8s.io/client-go/rest"
+)
+
+// BackendStatusesGetter has a method to return a BackendStatusInterface.
+// A group's client should implement this interface.
+type BackendStatusesGetter interface {
+	BackendStatuses() BackendStatusInterface
+}
+
+// BackendStatusInterface has methods to work with BackendStatus resources.
+type BackendStatusInterface interface {
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha1.BackendStatus, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.BackendStatusList, error)
+	BackendStatusExpansion
+}
+
+// backendStatuses implements BackendStatusInterface
+type backendStatuses struct {
+	client rest.Interface
+}
+
+// newBackendStatuses returns a BackendStatuses
+func newBackendStatuses(c *CoreV1alpha1Client) *backendStatuses {
+	return &backendStatuses{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the backendStatus, and returns the corresponding backendStatus object, and an error if there is any.
+func (c *backendStatuses) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.BackendStatus, err error) {
+	result = &v1alpha1.BackendStatus{}
+	err = c.client.Get().
+		Resource("backendstatuses").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of BackendStatuses that match those selectors.
+func (c *backendStatuses) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.BackendStatusList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.BackendStatusList{}
+	err = c.client.Get().
+		Resource("backendstatuses").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
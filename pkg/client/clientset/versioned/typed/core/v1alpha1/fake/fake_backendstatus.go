@@ -0,0 +1,68 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeBackendStatuses implements BackendStatusInterface
+type FakeBackendStatuses struct {
+	Fake *FakeCoreV1alpha1
+}
+
+var backendstatusesResource = schema.GroupVersionResource{Group: "core.kjournal", Version: "v1alpha1", Resource: "backendstatuses"}
+
+var backendstatusesKind = schema.GroupVersionKind{Group: "core.kjournal", Version: "v1alpha1", Kind: "BackendStatus"}
+
+// Get takes name of the backendStatus, and returns the corresponding backendStatus object, and an error if there is any.
+func (c *FakeBackendStatuses) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.BackendStatus, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(backendstatusesResource, name), &v1alpha1.BackendStatus{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.BackendStatus), err
+}
+
+// List takes label and field selectors, and returns the list of BackendStatuses that match those selectors.
+func (c *FakeBackendStatuses) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.BackendStatusList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(backendstatusesResource, backendstatusesKind, opts), &v1alpha1.BackendStatusList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.BackendStatusList{ListMeta: obj.(*v1alpha1.BackendStatusList).ListMeta}
+	for _, item := range obj.(*v1alpha1.BackendStatusList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
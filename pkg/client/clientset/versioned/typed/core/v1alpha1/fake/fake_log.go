@@ -0,0 +1,65 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeLogs implements LogInterface
+type FakeLogs struct {
+	Fake *FakeCoreV1alpha1
+}
+
+var logsResource = schema.GroupVersionResource{Group: "core.kjournal", Version: "v1alpha1", Resource: "logs"}
+
+var logsKind = schema.GroupVersionKind{Group: "core.kjournal", Version: "v1alpha1", Kind: "Log"}
+
+// List takes label and field selectors, and returns the list of Logs that match those selectors.
+func (c *FakeLogs) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.LogList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(logsResource, logsKind, opts), &v1alpha1.LogList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.LogList{ListMeta: obj.(*v1alpha1.LogList).ListMeta}
+	for _, item := range obj.(*v1alpha1.LogList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested logs.
+func (c *FakeLogs) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(logsResource, opts))
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeSearches implements SearchInterface
+type FakeSearches struct {
+	Fake *FakeCoreV1alpha1
+}
+
+var searchesResource = schema.GroupVersionResource{Group: "core.kjournal", Version: "v1alpha1", Resource: "searches"}
+
+var searchesKind = schema.GroupVersionKind{Group: "core.kjournal", Version: "v1alpha1", Kind: "Search"}
+
+// Get takes name of the search, and returns the corresponding search object, and an error if there is any.
+func (c *FakeSearches) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.Search, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(searchesResource, name), &v1alpha1.Search{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.Search), err
+}
+
+// List takes label and field selectors, and returns the list of Searches that match those selectors.
+func (c *FakeSearches) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.SearchList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(searchesResource, searchesKind, opts), &v1alpha1.SearchList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.SearchList{ListMeta: obj.(*v1alpha1.SearchList).ListMeta}
+	for _, item := range obj.(*v1alpha1.SearchList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Create takes the representation of a search and creates it.  Returns the server's representation of the search, and an error, if there is any.
+func (c *FakeSearches) Create(ctx context.Context, search *v1alpha1.Search, opts v1.CreateOptions) (result *v1alpha1.Search, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(searchesResource, search), &v1alpha1.Search{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.Search), err
+}
+
+// Delete takes name of the search and deletes it. Returns an error if one occurs.
+func (c *FakeSearches) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteActionWithOptions(searchesResource, name, opts), &v1alpha1.Search{})
+	return err
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	scheme "github.com/raffis/kjournal/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	rest "k8s.io/client-go/rest"
+)
+
+// SearchesGetter has a method to return a SearchInterface.
+// A group's client should implement this interface.
+type SearchesGetter interface {
+	Searches() SearchInterface
+}
+
+// SearchInterface has methods to work with Search resources.
+type SearchInterface interface {
+	Create(ctx context.Context, search *v1alpha1.Search, opts v1.CreateOptions) (*v1alpha1.Search, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha1.Search, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.SearchList, error)
+	SearchExpansion
+}
+
+// searches implements SearchInterface
+type searches struct {
+	client rest.Interface
+}
+
+// newSearches returns a Searches
+func newSearches(c *CoreV1alpha1Client) *searches {
+	return &searches{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the search, and returns the corresponding search object, and an error if there is any.
+func (c *searches) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.Search, err error) {
+	result = &v1alpha1.Search{}
+	err = c.client.Get().
+		Resource("searches").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of Searches that match those selectors.
+func (c *searches) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.SearchList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.SearchList{}
+	err = c.client.Get().
+		Resource("searches").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Create takes the representation of a search and creates it.  Returns the server's representation of the search, and an error, if there is any.
+func (c *searches) Create(ctx context.Context, search *v1alpha1.Search, opts v1.CreateOptions) (result *v1alpha1.Search, err error) {
+	result = &v1alpha1.Search{}
+	err = c.client.Post().
+		Resource("searches").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(search).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the search and deletes it. Returns an error if one occurs.
+func (c *searches) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("searches").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	time "time"
+
+	corev1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	versioned "github.com/raffis/kjournal/pkg/client/clientset/versioned"
+	internalinterfaces "github.com/raffis/kjournal/pkg/client/informers/externalversions/internalinterfaces"
+	v1alpha1 "github.com/raffis/kjournal/pkg/client/listers/core/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// AuditEventInformer provides access to a shared informer and lister for
+// AuditEvents.
+type AuditEventInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1alpha1.AuditEventLister
+}
+
+type auditEventInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// NewAuditEventInformer constructs a new informer for AuditEvent type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewAuditEventInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredAuditEventInformer(client, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredAuditEventInformer constructs a new informer for AuditEvent type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredAuditEventInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.CoreV1alpha1().AuditEvents().List(context.TODO(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.CoreV1alpha1().AuditEvents().Watch(context.TODO(), options)
+			},
+		},
+		&corev1alpha1.AuditEvent{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *auditEventInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredAuditEventInformer(client, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *auditEventInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&corev1alpha1.AuditEvent{}, f.defaultInformer)
+}
+
+func (f *auditEventInformer) Lister() v1alpha1.AuditEventLister {
+	return v1alpha1.NewAuditEventLister(f.Informer().GetIndexer())
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// AuditEventLister helps list AuditEvents.
+// All objects returned here must be treated as read-only.
+type AuditEventLister interface {
+	// List lists all AuditEvents in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.AuditEvent, err error)
+	// Get retrieves the AuditEvent from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1alpha1.AuditEvent, error)
+	AuditEventListerExpansion
+}
+
+// auditEventLister implements the AuditEventLister interface.
+type auditEventLister struct {
+	indexer cache.Indexer
+}
+
+// NewAuditEventLister returns a new AuditEventLister.
+func NewAuditEventLister(indexer cache.Indexer) AuditEventLister {
+	return &auditEventLister{indexer: indexer}
+}
+
+// List lists all AuditEvents in the indexer.
+func (s *auditEventLister) List(selector labels.Selector) (ret []*v1alpha1.AuditEvent, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.AuditEvent))
+	})
+	return ret, err
+}
+
+// Get retrieves the AuditEvent from the index for a given name.
+func (s *auditEventLister) Get(name string) (*v1alpha1.AuditEvent, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("auditevent"), name)
+	}
+	return obj.(*v1alpha1.AuditEvent), nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ContainerLogLister helps list ContainerLogs.
+// All objects returned here must be treated as read-only.
+type ContainerLogLister interface {
+	// List lists all ContainerLogs in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.ContainerLog, err error)
+	// ContainerLogs returns an object that can list and get ContainerLogs.
+	ContainerLogs(namespace string) ContainerLogNamespaceLister
+	ContainerLogListerExpansion
+}
+
+// containerLogLister implements the ContainerLogLister interface.
+type containerLogLister struct {
+	indexer cache.Indexer
+}
+
+// NewContainerLogLister returns a new ContainerLogLister.
+func NewContainerLogLister(indexer cache.Indexer) ContainerLogLister {
+	return &containerLogLister{indexer: indexer}
+}
+
+// List lists all ContainerLogs in the indexer.
+func (s *containerLogLister) List(selector labels.Selector) (ret []*v1alpha1.ContainerLog, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.ContainerLog))
+	})
+	return ret, err
+}
+
+// ContainerLogs returns an object that can list and get ContainerLogs.
+func (s *containerLogLister) ContainerLogs(namespace string) ContainerLogNamespaceLister {
+	return containerLogNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// ContainerLogNamespaceLister helps list and get ContainerLogs.
+// All objects returned here must be treated as read-only.
+type ContainerLogNamespaceLister interface {
+	// List lists all ContainerLogs in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.ContainerLog, err error)
+	// Get retrieves the ContainerLog from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1alpha1.ContainerLog, error)
+	ContainerLogNamespaceListerExpansion
+}
+
+// containerLogNamespaceLister implements the ContainerLogNamespaceLister
+// interface.
+type containerLogNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all ContainerLogs in the indexer for a given namespace.
+func (s containerLogNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.ContainerLog, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.ContainerLog))
+	})
+	return ret, err
+}
+
+// Get retrieves the ContainerLog from the indexer for a given namespace and name.
+func (s containerLogNamespaceLister) Get(name string) (*v1alpha1.ContainerLog, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("containerlog"), name)
+	}
+	return obj.(*v1alpha1.ContainerLog), nil
+}
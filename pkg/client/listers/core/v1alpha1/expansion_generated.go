@@ -0,0 +1,50 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+// AuditEventListerExpansion allows custom methods to be added to
+// AuditEventLister.
+type AuditEventListerExpansion interface{}
+
+// BackendStatusListerExpansion allows custom methods to be added to
+// BackendStatusLister.
+type BackendStatusListerExpansion interface{}
+
+// ContainerLogListerExpansion allows custom methods to be added to
+// ContainerLogLister.
+type ContainerLogListerExpansion interface{}
+
+// ContainerLogNamespaceListerExpansion allows custom methods to be added to
+// ContainerLogNamespaceLister.
+type ContainerLogNamespaceListerExpansion interface{}
+
+// EventListerExpansion allows custom methods to be added to
+// EventLister.
+type EventListerExpansion interface{}
+
+// EventNamespaceListerExpansion allows custom methods to be added to
+// EventNamespaceLister.
+type EventNamespaceListerExpansion interface{}
+
+// LogListerExpansion allows custom methods to be added to
+// LogLister.
+type LogListerExpansion interface{}
+
+// SearchListerExpansion allows custom methods to be added to
+// SearchLister.
+type SearchListerExpansion interface{}
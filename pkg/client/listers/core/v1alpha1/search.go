@@ -0,0 +1,67 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// SearchLister helps list Searches.
+// All objects returned here must be treated as read-only.
+type SearchLister interface {
+	// List lists all Searches in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.Search, err error)
+	// Get retrieves the Search from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1alpha1.Search, error)
+	SearchListerExpansion
+}
+
+// searchLister implements the SearchLister interface.
+type searchLister struct {
+	indexer cache.Indexer
+}
+
+// NewSearchLister returns a new SearchLister.
+func NewSearchLister(indexer cache.Indexer) SearchLister {
+	return &searchLister{indexer: indexer}
+}
+
+// List lists all Searches in the indexer.
+func (s *searchLister) List(selector labels.Selector) (ret []*v1alpha1.Search, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.Search))
+	})
+	return ret, err
+}
+
+// Get retrieves the Search from the index for a given name.
+func (s *searchLister) Get(name string) (*v1alpha1.Search, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("search"), name)
+	}
+	return obj.(*v1alpha1.Search), nil
+}
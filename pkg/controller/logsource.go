@@ -0,0 +1,325 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	configv1alpha1 "github.com/raffis/kjournal/pkg/apis/config/v1alpha1"
+	"github.com/raffis/kjournal/pkg/storage"
+)
+
+var LogSourceGroupVersionResource = schema.GroupVersionResource{
+	Group:    "config.kjournal",
+	Version:  "v1alpha1",
+	Resource: "logsources",
+}
+
+// All LogSources are reconciled at once since conflicting LogSources for the same resource
+// depend on each other. Therefore a single queue key is used.
+const syncKey = "logsources"
+
+// LogSourceController binds the api storage to LogSource resources and reports
+// the backend and query state back to their status.
+// Every replica binds the LogSources to serve their apis but only the leader updates their status,
+// otherwise replicas would keep overwriting each others status.
+type LogSourceController struct {
+	client   dynamic.Interface
+	provider storage.BindingProvider
+	informer cache.SharedIndexInformer
+	queue    workqueue.RateLimitingInterface
+	bound    map[string]boundSource
+	leading  int32
+}
+
+type boundSource struct {
+	uid        types.UID
+	generation int64
+}
+
+func NewLogSourceController(client dynamic.Interface, provider storage.BindingProvider, resyncPeriod time.Duration) *LogSourceController {
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(client, resyncPeriod)
+
+	c := &LogSourceController{
+		client:   client,
+		provider: provider,
+		informer: factory.ForResource(LogSourceGroupVersionResource).Informer(),
+		queue:    workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "logsources"),
+		bound:    make(map[string]boundSource),
+	}
+
+	c.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.queue.Add(syncKey) },
+		UpdateFunc: func(oldObj, newObj interface{}) { c.queue.Add(syncKey) },
+		DeleteFunc: func(obj interface{}) { c.queue.Add(syncKey) },
+	})
+
+	return c
+}
+
+// StartLeading enables status updates, it is called once the replica acquired the leader lease.
+// If leader election is disabled it must be called before Run.
+func (c *LogSourceController) StartLeading() {
+	atomic.StoreInt32(&c.leading, 1)
+	c.queue.Add(syncKey)
+}
+
+// StopLeading disables status updates once the replica lost the leader lease
+func (c *LogSourceController) StopLeading() {
+	atomic.StoreInt32(&c.leading, 0)
+}
+
+func (c *LogSourceController) isLeading() bool {
+	return atomic.LoadInt32(&c.leading) == 1
+}
+
+// Run starts the controller and blocks until the context is done
+func (c *LogSourceController) Run(ctx context.Context) error {
+	defer utilruntime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.InfoS("starting logsource controller")
+	go c.informer.Run(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), c.informer.HasSynced) {
+		return fmt.Errorf("failed to wait for logsource informer to sync")
+	}
+
+	go wait.UntilWithContext(ctx, c.worker, time.Second)
+
+	<-ctx.Done()
+	klog.InfoS("shutting down logsource controller")
+	return nil
+}
+
+func (c *LogSourceController) worker(ctx context.Context) {
+	for c.processNextItem(ctx) {
+	}
+}
+
+func (c *LogSourceController) processNextItem(ctx context.Context) bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+
+	defer c.queue.Done(key)
+
+	if err := c.sync(ctx); err != nil {
+		klog.ErrorS(err, "failed to sync logsources")
+		c.queue.AddRateLimited(key)
+		return true
+	}
+
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *LogSourceController) sync(ctx context.Context) error {
+	var sources []configv1alpha1.LogSource
+	for _, obj := range c.informer.GetStore().List() {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+
+		var source configv1alpha1.LogSource
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &source); err != nil {
+			klog.ErrorS(err, "failed to decode logsource", "name", u.GetName())
+			continue
+		}
+
+		sources = append(sources, source)
+	}
+
+	// The oldest LogSource wins if multiple ones exist for the same resource
+	sort.Slice(sources, func(i, j int) bool {
+		if sources[i].CreationTimestamp.Equal(&sources[j].CreationTimestamp) {
+			return sources[i].Name < sources[j].Name
+		}
+
+		return sources[i].CreationTimestamp.Before(&sources[j].CreationTimestamp)
+	})
+
+	backendErr := c.provider.Check(ctx)
+	winners := make(map[string]string)
+	var errs []error
+
+	for i := range sources {
+		source := &sources[i]
+		status := source.Status.DeepCopy()
+		status.ObservedGeneration = source.Generation
+		setBackendCondition(status, source.Generation, backendErr)
+
+		if winner, ok := winners[source.Spec.Resource]; ok {
+			meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+				Type:               configv1alpha1.LogSourceBoundCondition,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: source.Generation,
+				Reason:             "Conflict",
+				Message:            fmt.Sprintf("resource %s is already bound by logsource %s", source.Spec.Resource, winner),
+			})
+		} else if err := c.bind(source); err != nil {
+			meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+				Type:               configv1alpha1.LogSourceBoundCondition,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: source.Generation,
+				Reason:             "BindFailed",
+				Message:            err.Error(),
+			})
+		} else {
+			winners[source.Spec.Resource] = source.Name
+			meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+				Type:               configv1alpha1.LogSourceBoundCondition,
+				Status:             metav1.ConditionTrue,
+				ObservedGeneration: source.Generation,
+				Reason:             "Bound",
+				Message:            fmt.Sprintf("resource %s is served from this logsource", source.Spec.Resource),
+			})
+
+			if backendErr == nil {
+				setStorageCondition(status, source.Generation, c.provider.CheckBinding(ctx, &source.Spec))
+			}
+
+			setQueryStatus(status, source.Generation, c.provider.QueryStatus(source.Spec.Resource))
+		}
+
+		if !c.isLeading() {
+			continue
+		}
+
+		if err := c.updateStatus(ctx, source, status); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for resource := range c.bound {
+		if _, ok := winners[resource]; !ok {
+			klog.InfoS("unbind resource", "resource", resource)
+			c.provider.Unbind(resource)
+			delete(c.bound, resource)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to update logsource status: %v", errs)
+	}
+
+	return nil
+}
+
+func (c *LogSourceController) bind(source *configv1alpha1.LogSource) error {
+	current, ok := c.bound[source.Spec.Resource]
+	if ok && current.uid == source.UID && current.generation == source.Generation {
+		return nil
+	}
+
+	klog.InfoS("bind resource", "resource", source.Spec.Resource, "logsource", source.Name)
+	apiBinding := source.Spec.DeepCopy()
+	if err := c.provider.Bind(apiBinding); err != nil {
+		return err
+	}
+
+	c.bound[source.Spec.Resource] = boundSource{
+		uid:        source.UID,
+		generation: source.Generation,
+	}
+
+	return nil
+}
+
+func (c *LogSourceController) updateStatus(ctx context.Context, source *configv1alpha1.LogSource, status *configv1alpha1.LogSourceStatus) error {
+	if equality.Semantic.DeepEqual(&source.Status, status) {
+		return nil
+	}
+
+	source.Status = *status
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(source)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.client.Resource(LogSourceGroupVersionResource).UpdateStatus(ctx, &unstructured.Unstructured{Object: obj}, metav1.UpdateOptions{})
+	return err
+}
+
+func setBackendCondition(status *configv1alpha1.LogSourceStatus, generation int64, err error) {
+	condition := metav1.Condition{
+		Type:               configv1alpha1.LogSourceBackendReachableCondition,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "Reachable",
+		Message:            "storage backend is reachable",
+	}
+
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Unreachable"
+		condition.Message = err.Error()
+	}
+
+	meta.SetStatusCondition(&status.Conditions, condition)
+}
+
+func setStorageCondition(status *configv1alpha1.LogSourceStatus, generation int64, err error) {
+	condition := metav1.Condition{
+		Type:               configv1alpha1.LogSourceStorageResolvedCondition,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "Resolved",
+		Message:            "storage resolves within the backend",
+	}
+
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Unresolved"
+		condition.Message = err.Error()
+	}
+
+	meta.SetStatusCondition(&status.Conditions, condition)
+}
+
+func setQueryStatus(status *configv1alpha1.LogSourceStatus, generation int64, queryStatus storage.QueryStatus) {
+	if queryStatus.LastError != nil {
+		status.LastQueryError = queryStatus.LastError.Error()
+		t := metav1.NewTime(queryStatus.LastErrorTime.Truncate(time.Second))
+		status.LastQueryErrorTime = &t
+	}
+
+	if !queryStatus.Queried {
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:               configv1alpha1.LogSourceQuerySucceededCondition,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "QuerySucceeded",
+		Message:            "last query succeeded",
+	}
+
+	if queryStatus.Err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "QueryFailed"
+		condition.Message = queryStatus.Err.Error()
+	}
+
+	meta.SetStatusCondition(&status.Conditions, condition)
+}
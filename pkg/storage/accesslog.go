@@ -0,0 +1,430 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/klog/v2"
+
+	configv1alpha1 "github.com/raffis/kjournal/pkg/apis/config/v1alpha1"
+)
+
+const accessLogWebhookQueueSize = 1000
+
+// AccessLogEntry describes a single list, watch or search request
+type AccessLogEntry struct {
+	Timestamp time.Time  `json:"timestamp"`
+	User      string     `json:"user"`
+	Groups    []string   `json:"groups,omitempty"`
+	Verb      string     `json:"verb"`
+	Resource  string     `json:"resource"`
+	Namespace string     `json:"namespace,omitempty"`
+	Selector  string     `json:"selector,omitempty"`
+	Indices   []string   `json:"indices,omitempty"`
+	Query     string     `json:"query,omitempty"`
+	Limit     int64      `json:"limit,omitempty"`
+	Continue  string     `json:"continue,omitempty"`
+	From      *time.Time `json:"from,omitempty"`
+	To        *time.Time `json:"to,omitempty"`
+	Hits      int        `json:"hits"`
+	Duration  string     `json:"duration"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// QueryResolver is implemented by storages which can resolve the backend query of a request
+type QueryResolver interface {
+	// Query returns the queried indices and the translated backend query, it is redacted if the backend is configured to do so
+	Query(ctx context.Context, options *metainternalversion.ListOptions) (indices []string, query string, err error)
+}
+
+// AccessLogger writes access log entries to a sink
+type AccessLogger interface {
+	Log(entry AccessLogEntry)
+}
+
+// NewAccessLogger creates an access logger for the configured sink.
+// It returns nil if the access log is not enabled.
+func NewAccessLogger(conf *configv1alpha1.AccessLog) (AccessLogger, error) {
+	if conf == nil {
+		return nil, nil
+	}
+
+	switch conf.Sink {
+	case "", configv1alpha1.AccessLogSinkStdout:
+		return &writerAccessLogger{w: os.Stdout}, nil
+	case configv1alpha1.AccessLogSinkFile:
+		f, err := os.OpenFile(conf.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to open access log file", err)
+		}
+
+		return &writerAccessLogger{w: f}, nil
+	case configv1alpha1.AccessLogSinkWebhook:
+		return newWebhookAccessLogger(conf.Webhook)
+	default:
+		return nil, fmt.Errorf("unsupported access log sink %s", conf.Sink)
+	}
+}
+
+// writerAccessLogger writes json lines
+type writerAccessLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *writerAccessLogger) Log(entry AccessLogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Keep the translated queries readable
+	enc := json.NewEncoder(l.w)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(entry); err != nil {
+		klog.ErrorS(err, "failed to write access log entry")
+	}
+}
+
+// webhookAccessLogger posts the entries to a webhook.
+// Entries are queued and sent in the background, if the queue is full entries are dropped.
+type webhookAccessLogger struct {
+	url    string
+	client *http.Client
+	queue  chan AccessLogEntry
+}
+
+func newWebhookAccessLogger(conf configv1alpha1.AccessLogWebhook) (AccessLogger, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed create cert pool", err)
+	}
+
+	if conf.TLS.CACert != "" {
+		cert, err := os.ReadFile(conf.TLS.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to load cacert", err)
+		}
+
+		pool.AppendCertsFromPEM(cert)
+	}
+
+	timeout := conf.Timeout.Duration
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	l := &webhookAccessLogger{
+		url: conf.URL,
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: conf.TLS.AllowInsecure,
+					RootCAs:            pool,
+					ServerName:         conf.TLS.ServerName,
+				},
+			},
+		},
+		queue: make(chan AccessLogEntry, accessLogWebhookQueueSize),
+	}
+
+	go l.run()
+	return l, nil
+}
+
+func (l *webhookAccessLogger) Log(entry AccessLogEntry) {
+	select {
+	case l.queue <- entry:
+	default:
+		klog.ErrorS(nil, "access log webhook queue is full, dropping entry", "user", entry.User, "resource", entry.Resource)
+	}
+}
+
+func (l *webhookAccessLogger) run() {
+	for entry := range l.queue {
+		if err := l.send(entry); err != nil {
+			klog.ErrorS(err, "failed to send access log entry", "url", l.url)
+		}
+	}
+}
+
+func (l *webhookAccessLogger) send(entry AccessLogEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	res, err := l.client.Post(l.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %s", res.Status)
+	}
+
+	return nil
+}
+
+var _ rest.Scoper = &accessLogStorage{}
+var _ rest.Storage = &accessLogStorage{}
+var _ rest.Lister = &accessLogStorage{}
+var _ rest.Watcher = &accessLogStorage{}
+var _ rest.TableConvertor = &accessLogStorage{}
+var _ Searcher = &accessLogStorage{}
+
+// accessLogStorage writes an access log entry for every list and watch request
+type accessLogStorage struct {
+	storage       rest.Storage
+	groupResource schema.GroupResource
+	logger        AccessLogger
+}
+
+// WithAccessLog wraps the storage with the access logger
+func WithAccessLog(storage rest.Storage, groupResource schema.GroupResource, logger AccessLogger) rest.Storage {
+	if logger == nil {
+		return storage
+	}
+
+	return &accessLogStorage{
+		storage:       storage,
+		groupResource: groupResource,
+		logger:        logger,
+	}
+}
+
+func (s *accessLogStorage) New() runtime.Object {
+	return s.storage.New()
+}
+
+func (s *accessLogStorage) Destroy() {
+	s.storage.Destroy()
+}
+
+func (s *accessLogStorage) NewList() runtime.Object {
+	if lister, ok := s.storage.(rest.Lister); ok {
+		return lister.NewList()
+	}
+
+	return nil
+}
+
+func (s *accessLogStorage) NamespaceScoped() bool {
+	if scoper, ok := s.storage.(rest.Scoper); ok {
+		return scoper.NamespaceScoped()
+	}
+
+	return false
+}
+
+func (s *accessLogStorage) List(ctx context.Context, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	lister, ok := s.storage.(rest.Lister)
+	if !ok {
+		return nil, apierrors.NewMethodNotSupported(s.groupResource, "list")
+	}
+
+	entry := s.entry(ctx, "list", options)
+	start := time.Now()
+
+	obj, err := lister.List(ctx, options)
+	if err == nil {
+		entry.Hits = meta.LenList(obj)
+	}
+
+	s.log(entry, start, err)
+	return obj, err
+}
+
+func (s *accessLogStorage) Watch(ctx context.Context, options *metainternalversion.ListOptions) (watch.Interface, error) {
+	watcher, ok := s.storage.(rest.Watcher)
+	if !ok {
+		return nil, apierrors.NewMethodNotSupported(s.groupResource, "watch")
+	}
+
+	entry := s.entry(ctx, "watch", options)
+	start := time.Now()
+
+	w, err := watcher.Watch(ctx, options)
+	if err != nil {
+		s.log(entry, start, err)
+		return nil, err
+	}
+
+	lw := &accessLogWatch{
+		Interface: w,
+		ch:        make(chan watch.Event),
+		stop:      make(chan struct{}),
+	}
+
+	go lw.forward(func(hits int) {
+		entry.Hits = hits
+		s.log(entry, start, nil)
+	})
+
+	return lw, nil
+}
+
+// SubmitSearch writes an access log entry for the submitted search
+func (s *accessLogStorage) SubmitSearch(ctx context.Context, options *metainternalversion.ListOptions) (string, error) {
+	searcher, err := searcherOf(s.storage, s.groupResource)
+	if err != nil {
+		return "", err
+	}
+
+	entry := s.entry(ctx, "search", options)
+	start := time.Now()
+
+	id, err := searcher.SubmitSearch(ctx, options)
+	s.log(entry, start, err)
+	return id, err
+}
+
+func (s *accessLogStorage) SearchProgress(ctx context.Context, id string) (SearchProgress, error) {
+	searcher, err := searcherOf(s.storage, s.groupResource)
+	if err != nil {
+		return SearchProgress{}, err
+	}
+
+	return searcher.SearchProgress(ctx, id)
+}
+
+func (s *accessLogStorage) SearchResults(ctx context.Context, id string, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	searcher, err := searcherOf(s.storage, s.groupResource)
+	if err != nil {
+		return nil, err
+	}
+
+	return searcher.SearchResults(ctx, id, options)
+}
+
+func (s *accessLogStorage) DeleteSearch(ctx context.Context, id string) error {
+	searcher, err := searcherOf(s.storage, s.groupResource)
+	if err != nil {
+		return err
+	}
+
+	return searcher.DeleteSearch(ctx, id)
+}
+
+func (s *accessLogStorage) ConvertToTable(ctx context.Context, obj runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	if convertor, ok := s.storage.(rest.TableConvertor); ok {
+		return convertor.ConvertToTable(ctx, obj, tableOptions)
+	}
+
+	return rest.NewDefaultTableConvertor(s.groupResource).ConvertToTable(ctx, obj, tableOptions)
+}
+
+// entry creates the access log entry from the request
+func (s *accessLogStorage) entry(ctx context.Context, verb string, options *metainternalversion.ListOptions) AccessLogEntry {
+	entry := AccessLogEntry{
+		Timestamp: time.Now(),
+		User:      user.Anonymous,
+		Verb:      verb,
+		Resource:  s.groupResource.String(),
+		Limit:     options.Limit,
+		Continue:  options.Continue,
+	}
+
+	if info, ok := request.UserFrom(ctx); ok {
+		entry.User = info.GetName()
+		entry.Groups = info.GetGroups()
+	}
+
+	entry.Namespace, _ = request.NamespaceFrom(ctx)
+	if options.LabelSelector != nil {
+		entry.Selector = options.LabelSelector.String()
+	}
+
+	if resolver, ok := s.storage.(TimeRangeResolver); ok {
+		if from, to, err := resolver.TimeRange(ctx, options); err == nil {
+			entry.From = &from
+			entry.To = &to
+		}
+	}
+
+	if resolver, ok := s.storage.(QueryResolver); ok {
+		if indices, query, err := resolver.Query(ctx, options); err == nil {
+			entry.Indices = indices
+			entry.Query = query
+		}
+	}
+
+	return entry
+}
+
+func (s *accessLogStorage) log(entry AccessLogEntry, start time.Time, err error) {
+	entry.Duration = time.Since(start).String()
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	s.logger.Log(entry)
+}
+
+// accessLogWatch counts the events sent to the client and reports them once the watch ends
+type accessLogWatch struct {
+	watch.Interface
+	ch   chan watch.Event
+	stop chan struct{}
+	once sync.Once
+}
+
+func (w *accessLogWatch) forward(done func(hits int)) {
+	var hits int
+	defer func() {
+		close(w.ch)
+		done(hits)
+	}()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case event, ok := <-w.Interface.ResultChan():
+			if !ok {
+				return
+			}
+
+			select {
+			case w.ch <- event:
+				if event.Type != watch.Error {
+					hits++
+				}
+			case <-w.stop:
+				return
+			}
+		}
+	}
+}
+
+func (w *accessLogWatch) ResultChan() <-chan watch.Event {
+	return w.ch
+}
+
+func (w *accessLogWatch) Stop() {
+	w.once.Do(func() {
+		close(w.stop)
+	})
+
+	w.Interface.Stop()
+}
@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	configv1alpha1 "github.com/raffis/kjournal/pkg/apis/config/v1alpha1"
+)
+
+// chanAccessLogger sends the entries to a channel
+type chanAccessLogger chan AccessLogEntry
+
+func (l chanAccessLogger) Log(entry AccessLogEntry) {
+	l <- entry
+}
+
+func TestAccessLog(t *testing.T) {
+	from := time.Date(2022, 10, 19, 12, 30, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	tests := []struct {
+		name      string
+		watch     bool
+		ctx       context.Context
+		namespace string
+		selector  string
+		limit     int64
+		backend   *fakeStorage
+		expected  AccessLogEntry
+	}{
+		{
+			name:      "List entry includes the request, the resolved query and the number of hits",
+			ctx:       withUser("jane", "developers"),
+			namespace: "default",
+			selector:  "payload.level=error",
+			limit:     500,
+			backend: &fakeStorage{
+				from:    from,
+				to:      to,
+				indices: []string{"logs-default.2022.10.19", "logs-default.2022.10.20"},
+				query:   `{"query":{"bool":{}}}`,
+				items:   3,
+			},
+			expected: AccessLogEntry{
+				User:      "jane",
+				Groups:    []string{"developers"},
+				Verb:      "list",
+				Resource:  testGroupResource.String(),
+				Namespace: "default",
+				Selector:  "payload.level=error",
+				Indices:   []string{"logs-default.2022.10.19", "logs-default.2022.10.20"},
+				Query:     `{"query":{"bool":{}}}`,
+				Limit:     500,
+				From:      &from,
+				To:        &to,
+				Hits:      3,
+			},
+		},
+		{
+			name: "Redacted query is logged as resolved by the backend",
+			ctx:  withUser("jane"),
+			backend: &fakeStorage{
+				from:    from,
+				to:      to,
+				indices: []string{"logs-*"},
+				query:   "<redacted>",
+			},
+			expected: AccessLogEntry{
+				User:     "jane",
+				Verb:     "list",
+				Resource: testGroupResource.String(),
+				Indices:  []string{"logs-*"},
+				Query:    "<redacted>",
+				From:     &from,
+				To:       &to,
+			},
+		},
+		{
+			name: "Failed requests include the error",
+			ctx:  withUser("jane"),
+			backend: &fakeStorage{
+				from: from,
+				to:   to,
+				err:  errors.New("backend unavailable"),
+			},
+			expected: AccessLogEntry{
+				User:     "jane",
+				Verb:     "list",
+				Resource: testGroupResource.String(),
+				From:     &from,
+				To:       &to,
+				Error:    "backend unavailable",
+			},
+		},
+		{
+			name:  "Watch entry is written once the watch ends and counts the sent events",
+			watch: true,
+			ctx:   withUser("jane"),
+			backend: &fakeStorage{
+				from:    from,
+				to:      to,
+				indices: []string{"logs-*"},
+				query:   `{"query":{"bool":{}}}`,
+				items:   2,
+			},
+			expected: AccessLogEntry{
+				User:     "jane",
+				Verb:     "watch",
+				Resource: testGroupResource.String(),
+				Indices:  []string{"logs-*"},
+				Query:    `{"query":{"bool":{}}}`,
+				From:     &from,
+				To:       &to,
+				Hits:     2,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries := make(chanAccessLogger, 1)
+			storage := WithAccessLog(test.backend, testGroupResource, entries)
+
+			options := &metainternalversion.ListOptions{Limit: test.limit}
+			if test.selector != "" {
+				selector, err := labels.Parse(test.selector)
+				assert.NilError(t, err)
+				options.LabelSelector = selector
+			}
+
+			ctx := request.WithNamespace(test.ctx, test.namespace)
+			if test.watch {
+				w, err := storage.(rest.Watcher).Watch(ctx, options)
+				assert.NilError(t, err)
+
+				for i := 0; i < test.backend.items; i++ {
+					<-w.ResultChan()
+				}
+
+				w.Stop()
+			} else {
+				_, _ = storage.(rest.Lister).List(ctx, options)
+			}
+
+			select {
+			case entry := <-entries:
+				assert.Assert(t, !entry.Timestamp.IsZero())
+				assert.Assert(t, entry.Duration != "")
+				entry.Timestamp = time.Time{}
+				entry.Duration = ""
+				assert.DeepEqual(t, test.expected, entry)
+			case <-time.After(time.Second):
+				t.Fatal("no access log entry written")
+			}
+		})
+	}
+}
+
+func TestAccessLogSink(t *testing.T) {
+	entry := AccessLogEntry{
+		Timestamp: time.Date(2022, 10, 20, 12, 30, 0, 0, time.UTC),
+		User:      "jane",
+		Verb:      "list",
+		Resource:  testGroupResource.String(),
+		Indices:   []string{"logs-*"},
+		Query:     "<redacted>",
+		Hits:      12,
+		Duration:  "48.3ms",
+	}
+
+	expected := `{"timestamp":"2022-10-20T12:30:00Z","user":"jane","verb":"list","resource":"containerlogs.core.kjournal","indices":["logs-*"],"query":"<redacted>","hits":12,"duration":"48.3ms"}`
+
+	t.Run("File sink appends json lines", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "access.log")
+		logger, err := NewAccessLogger(&configv1alpha1.AccessLog{
+			Sink: configv1alpha1.AccessLogSinkFile,
+			Path: path,
+		})
+		assert.NilError(t, err)
+
+		logger.Log(entry)
+		logger.Log(entry)
+
+		b, err := os.ReadFile(path)
+		assert.NilError(t, err)
+		assert.Equal(t, expected+"\n"+expected+"\n", string(b))
+	})
+
+	t.Run("Webhook sink posts the entries as json", func(t *testing.T) {
+		bodies := make(chan string, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			bodies <- string(b)
+		}))
+		defer srv.Close()
+
+		logger, err := NewAccessLogger(&configv1alpha1.AccessLog{
+			Sink:    configv1alpha1.AccessLogSinkWebhook,
+			Webhook: configv1alpha1.AccessLogWebhook{URL: srv.URL},
+		})
+		assert.NilError(t, err)
+
+		logger.Log(entry)
+
+		select {
+		case body := <-bodies:
+			var received AccessLogEntry
+			assert.NilError(t, json.NewDecoder(strings.NewReader(body)).Decode(&received))
+			assert.DeepEqual(t, entry, received)
+		case <-time.After(time.Second):
+			t.Fatal("no access log entry posted")
+		}
+	})
+
+	t.Run("Unsupported sinks are rejected", func(t *testing.T) {
+		_, err := NewAccessLogger(&configv1alpha1.AccessLog{Sink: "syslog"})
+		assert.ErrorContains(t, err, "unsupported access log sink syslog")
+	})
+}
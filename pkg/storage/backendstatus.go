@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	corev1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+)
+
+var _ rest.Scoper = &backendStatusStorage{}
+var _ rest.Storage = &backendStatusStorage{}
+var _ rest.Getter = &backendStatusStorage{}
+var _ rest.Lister = &backendStatusStorage{}
+var _ rest.TableConvertor = &backendStatusStorage{}
+
+var backendStatusTableColumns = []metav1.TableColumnDefinition{
+	{Name: "NAME", Type: "string", Format: "name", Description: "The name of the storage backend."},
+	{Name: "REACHABLE", Type: "boolean", Description: "Whether the storage backend responds."},
+	{Name: "LATENCY", Type: "string", Description: "The duration the storage backend took to respond."},
+	{Name: "ERROR", Type: "string", Description: "The error if the storage backend is not reachable."},
+}
+
+// backendStatusStorage is a read only storage which probes the storage backend whenever it is read
+type backendStatusStorage struct {
+	checker HealthChecker
+}
+
+// NewBackendStatusStorage returns the storage of the backendstatuses resource
+func NewBackendStatusStorage(checker HealthChecker) rest.Storage {
+	return &backendStatusStorage{
+		checker: checker,
+	}
+}
+
+func (s *backendStatusStorage) New() runtime.Object {
+	return (&corev1alpha1.BackendStatus{}).New()
+}
+
+func (s *backendStatusStorage) NewList() runtime.Object {
+	return &corev1alpha1.BackendStatusList{}
+}
+
+func (s *backendStatusStorage) NamespaceScoped() bool {
+	return false
+}
+
+func (s *backendStatusStorage) Destroy() {
+}
+
+func (s *backendStatusStorage) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	if name != s.checker.Backend() {
+		return nil, apierrors.NewNotFound(corev1alpha1.Resource("backendstatuses"), name)
+	}
+
+	return s.probe(ctx), nil
+}
+
+func (s *backendStatusStorage) List(ctx context.Context, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	return &corev1alpha1.BackendStatusList{
+		Items: []corev1alpha1.BackendStatus{*s.probe(ctx)},
+	}, nil
+}
+
+func (s *backendStatusStorage) ConvertToTable(ctx context.Context, obj runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	table := &metav1.Table{
+		ColumnDefinitions: backendStatusTableColumns,
+	}
+
+	var items []corev1alpha1.BackendStatus
+	switch obj := obj.(type) {
+	case *corev1alpha1.BackendStatus:
+		items = append(items, *obj)
+	case *corev1alpha1.BackendStatusList:
+		items = obj.Items
+	}
+
+	for i := range items {
+		item := &items[i]
+		table.Rows = append(table.Rows, metav1.TableRow{
+			Object: runtime.RawExtension{Object: item},
+			Cells:  []interface{}{item.Name, item.Status.Reachable, item.Status.Latency.Duration.String(), item.Status.Error},
+		})
+	}
+
+	return table, nil
+}
+
+func (s *backendStatusStorage) probe(ctx context.Context) *corev1alpha1.BackendStatus {
+	health := Probe(ctx, s.checker)
+
+	obj := s.New().(*corev1alpha1.BackendStatus)
+	obj.Name = health.Backend
+	obj.Status = corev1alpha1.BackendStatusStatus{
+		Reachable:     health.Err == nil,
+		Latency:       metav1.Duration{Duration: health.Latency},
+		LastProbeTime: metav1.NewTime(health.ProbeTime),
+	}
+
+	if health.Err != nil {
+		obj.Status.Error = health.Err.Error()
+	}
+
+	for _, binding := range health.Bindings {
+		status := corev1alpha1.APIStatus{
+			Resource: binding.Resource,
+			Resolved: binding.Err == nil,
+		}
+
+		if binding.Err != nil {
+			status.Error = binding.Err.Error()
+		}
+
+		obj.Status.APIs = append(obj.Status.APIs, status)
+	}
+
+	return obj
+}
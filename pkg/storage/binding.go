@@ -0,0 +1,332 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/registry/generic"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"sigs.k8s.io/apiserver-runtime/pkg/builder/resource"
+
+	configv1alpha1 "github.com/raffis/kjournal/pkg/apis/config/v1alpha1"
+	"github.com/raffis/kjournal/pkg/utils"
+)
+
+// BindingProvider is a Provider whose api bindings can be changed at runtime.
+// It is used if the api bindings are managed through LogSource resources rather than the static config.
+type BindingProvider interface {
+	Provider
+	Bind(apiBinding *configv1alpha1.API) error
+	Unbind(resource string)
+	Check(ctx context.Context) error
+	CheckBinding(ctx context.Context, apiBinding *configv1alpha1.API) error
+	QueryStatus(resource string) QueryStatus
+}
+
+// QueryStatus describes the outcome of queries against a bound resource
+type QueryStatus struct {
+	// Queried is true if at least one query was executed since the resource was bound
+	Queried bool
+
+	// Err is the result of the most recent query
+	Err error
+
+	// LastError is the most recent query error
+	LastError error
+
+	// LastErrorTime is the time LastError happened
+	LastErrorTime time.Time
+}
+
+type bindingProvider struct {
+	backend      *configv1alpha1.Backend
+	backendType  string
+	restProvider RestProvider
+	check        BackendCheck
+	bindingCheck BindingCheck
+	accessLog    AccessLogger
+	clusterName  string
+	mu           sync.RWMutex
+	storages     map[string]*boundStorage
+}
+
+func NewBindingProvider(conf configv1alpha1.APIServerConfig) (BindingProvider, error) {
+	p := &bindingProvider{
+		backend:     &conf.Backend,
+		clusterName: conf.ClusterName,
+		storages:    make(map[string]*boundStorage),
+	}
+
+	t, err := getType(conf.Backend)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := Providers.Get(t)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unsupported provider", err)
+	}
+
+	p.restProvider = provider
+	p.backendType = t
+
+	if p.accessLog, err = NewAccessLogger(conf.AccessLog); err != nil {
+		return nil, err
+	}
+
+	if check, err := Checks.Get(t); err == nil {
+		p.check = check
+	}
+
+	if check, err := BindingChecks.Get(t); err == nil {
+		p.bindingCheck = check
+	}
+
+	return p, nil
+}
+
+func (p *bindingProvider) Provide(obj resource.Object, scheme *runtime.Scheme, getter generic.RESTOptionsGetter) (rest.Storage, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := obj.GetGroupVersionResource().Resource
+	if s, ok := p.storages[key]; ok {
+		return s, nil
+	}
+
+	s := &boundStorage{
+		obj:    obj,
+		scheme: scheme,
+		getter: getter,
+	}
+
+	p.storages[key] = s
+	return s, nil
+}
+
+func (p *bindingProvider) Bind(apiBinding *configv1alpha1.API) error {
+	p.mu.RLock()
+	s, ok := p.storages[apiBinding.Resource]
+	p.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%w: resource %s is not served", utils.ErrNotFound, apiBinding.Resource)
+	}
+
+	storage, err := p.restProvider(s.obj, s.scheme, s.getter, p.backend, withDefaultCluster(apiBinding, p.clusterName))
+	if err != nil {
+		return err
+	}
+
+	storage, searcher := wrapStorage(storage, s.obj.GetGroupVersionResource().GroupResource(), apiBinding, p.accessLog)
+	s.bind(storage, searcher, apiBinding)
+	return nil
+}
+
+func (p *bindingProvider) Unbind(resource string) {
+	p.mu.RLock()
+	s, ok := p.storages[resource]
+	p.mu.RUnlock()
+
+	if ok {
+		s.bind(nil, nil, nil)
+	}
+}
+
+func (p *bindingProvider) Check(ctx context.Context) error {
+	if p.check == nil {
+		return nil
+	}
+
+	return p.check(ctx, p.backend)
+}
+
+func (p *bindingProvider) Backend() string {
+	return p.backendType
+}
+
+func (p *bindingProvider) Bindings() []*configv1alpha1.API {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var bindings []*configv1alpha1.API
+	for _, s := range p.storages {
+		s.mu.RLock()
+		if s.apiBinding != nil {
+			bindings = append(bindings, s.apiBinding)
+		}
+		s.mu.RUnlock()
+	}
+
+	sort.Slice(bindings, func(i, j int) bool {
+		return bindings[i].Resource < bindings[j].Resource
+	})
+
+	return bindings
+}
+
+func (p *bindingProvider) CheckBinding(ctx context.Context, apiBinding *configv1alpha1.API) error {
+	if p.bindingCheck == nil {
+		return nil
+	}
+
+	return p.bindingCheck(ctx, p.backend, apiBinding)
+}
+
+func (p *bindingProvider) Searcher(resource string) (Searcher, error) {
+	p.mu.RLock()
+	s, ok := p.storages[resource]
+	p.mu.RUnlock()
+
+	if ok {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		if s.searcher != nil {
+			return s.searcher, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrSearchNotSupported, resource)
+}
+
+func (p *bindingProvider) QueryStatus(resource string) QueryStatus {
+	p.mu.RLock()
+	s, ok := p.storages[resource]
+	p.mu.RUnlock()
+
+	if !ok {
+		return QueryStatus{}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+var _ rest.Scoper = &boundStorage{}
+var _ rest.Storage = &boundStorage{}
+var _ rest.Lister = &boundStorage{}
+var _ rest.Watcher = &boundStorage{}
+var _ rest.TableConvertor = &boundStorage{}
+
+// boundStorage delegates to the storage built from the current api binding.
+// Requests fail with a service unavailable error as long as no binding exists.
+type boundStorage struct {
+	obj        resource.Object
+	scheme     *runtime.Scheme
+	getter     generic.RESTOptionsGetter
+	mu         sync.RWMutex
+	storage    rest.Storage
+	searcher   Searcher
+	apiBinding *configv1alpha1.API
+	status     QueryStatus
+}
+
+func (s *boundStorage) bind(storage rest.Storage, searcher Searcher, apiBinding *configv1alpha1.API) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.storage != nil {
+		s.storage.Destroy()
+	}
+
+	s.storage = storage
+	s.searcher = searcher
+	s.apiBinding = apiBinding
+	s.status = QueryStatus{}
+}
+
+func (s *boundStorage) current() (rest.Storage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.storage == nil {
+		return nil, apierrors.NewServiceUnavailable(fmt.Sprintf("no LogSource bound for resource %s", s.obj.GetGroupVersionResource().Resource))
+	}
+
+	return s.storage, nil
+}
+
+func (s *boundStorage) record(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status.Queried = true
+	s.status.Err = err
+
+	if err != nil {
+		s.status.LastError = err
+		s.status.LastErrorTime = time.Now()
+	}
+}
+
+func (s *boundStorage) New() runtime.Object {
+	return s.obj.New()
+}
+
+func (s *boundStorage) NewList() runtime.Object {
+	return s.obj.NewList()
+}
+
+func (s *boundStorage) NamespaceScoped() bool {
+	return s.obj.NamespaceScoped()
+}
+
+func (s *boundStorage) Destroy() {
+	s.bind(nil, nil, nil)
+}
+
+func (s *boundStorage) List(ctx context.Context, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	storage, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+
+	lister, ok := storage.(rest.Lister)
+	if !ok {
+		return nil, apierrors.NewMethodNotSupported(s.obj.GetGroupVersionResource().GroupResource(), "list")
+	}
+
+	obj, err := lister.List(ctx, options)
+	s.record(err)
+	return obj, err
+}
+
+func (s *boundStorage) Watch(ctx context.Context, options *metainternalversion.ListOptions) (watch.Interface, error) {
+	storage, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, ok := storage.(rest.Watcher)
+	if !ok {
+		return nil, apierrors.NewMethodNotSupported(s.obj.GetGroupVersionResource().GroupResource(), "watch")
+	}
+
+	w, err := watcher.Watch(ctx, options)
+	s.record(err)
+	return w, err
+}
+
+func (s *boundStorage) ConvertToTable(ctx context.Context, obj runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	storage, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+
+	convertor, ok := storage.(rest.TableConvertor)
+	if !ok {
+		return rest.NewDefaultTableConvertor(s.obj.GetGroupVersionResource().GroupResource()).ConvertToTable(ctx, obj, tableOptions)
+	}
+
+	return convertor.ConvertToTable(ctx, obj, tableOptions)
+}
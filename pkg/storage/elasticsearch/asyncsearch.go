@@ -0,0 +1,123 @@
+package elasticsearch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	"k8s.io/klog/v2"
+)
+
+// asyncSearchDeleteTimeout is the timeout to delete a stored async search once its results are consumed or abandoned
+const asyncSearchDeleteTimeout = 10 * time.Second
+
+type esAsyncResults struct {
+	ID        string    `json:"id"`
+	IsRunning bool      `json:"is_running"`
+	IsPartial bool      `json:"is_partial"`
+	Response  esResults `json:"response"`
+}
+
+// asyncSearch queries the indices using the async search api.
+// The search is submitted and polled until it completes or the context is cancelled.
+func (r *elasticsearchREST) asyncSearch(ctx context.Context, body io.Reader, indices []string, options *metainternalversion.ListOptions) (results esResults, err error) {
+	async, err := r.submitAsyncSearch(ctx, body, indices, options.Limit, false)
+	if err != nil {
+		return results, err
+	}
+
+	// Searches which did not complete within the wait timeout are stored until they are deleted or expire
+	if async.IsRunning {
+		defer r.deleteAsyncSearch(async.ID)
+	}
+
+	for async.IsRunning {
+		klog.InfoS("poll async search", "id", async.ID)
+		async, err = r.getAsyncSearch(ctx, async.ID, r.opts.Backend.AsyncSearch.WaitForCompletionTimeout)
+		if err != nil {
+			return results, err
+		}
+	}
+
+	return async.Response, nil
+}
+
+// submitAsyncSearch submits a search to the async search api
+func (r *elasticsearchREST) submitAsyncSearch(ctx context.Context, body io.Reader, indices []string, size int64, keepOnCompletion bool) (async esAsyncResults, err error) {
+	opts := r.opts.Backend.AsyncSearch
+	req := []func(*esapi.AsyncSearchSubmitRequest){
+		r.es.AsyncSearch.Submit.WithContext(ctx),
+		r.es.AsyncSearch.Submit.WithBody(body),
+		r.es.AsyncSearch.Submit.WithTrackTotalHits(false),
+		r.es.AsyncSearch.Submit.WithWaitForCompletionTimeout(opts.WaitForCompletionTimeout),
+		r.es.AsyncSearch.Submit.WithKeepAlive(opts.KeepAlive),
+		r.es.AsyncSearch.Submit.WithKeepOnCompletion(keepOnCompletion),
+	}
+
+	if r.opts.Backend.SearchTimeout != 0 {
+		req = append(req, r.es.AsyncSearch.Submit.WithTimeout(r.opts.Backend.SearchTimeout))
+	}
+
+	if len(indices) > 0 {
+		req = append(req, r.es.AsyncSearch.Submit.WithIndex(indices...))
+	}
+
+	if r.ignoreUnavailable() {
+		req = append(req, r.es.AsyncSearch.Submit.WithIgnoreUnavailable(true))
+	}
+
+	if size != 0 {
+		req = append(req, r.es.AsyncSearch.Submit.WithSize(int(size)))
+	}
+
+	res, err := r.es.AsyncSearch.Submit(req...)
+	if err != nil {
+		klog.ErrorS(err, "error getting response from es")
+		return async, err
+	}
+
+	defer res.Body.Close()
+	err = decodeResponse(res, &async)
+	return async, err
+}
+
+// getAsyncSearch returns the response of a stored async search, it blocks up to the wait timeout if the search is still running
+func (r *elasticsearchREST) getAsyncSearch(ctx context.Context, id string, wait time.Duration) (async esAsyncResults, err error) {
+	res, err := r.es.AsyncSearch.Get(id,
+		r.es.AsyncSearch.Get.WithContext(ctx),
+		r.es.AsyncSearch.Get.WithWaitForCompletionTimeout(wait),
+	)
+
+	if err != nil {
+		klog.ErrorS(err, "error getting response from es")
+		return async, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return async, apierrors.NewNotFound(r.groupResource, id)
+	}
+
+	err = decodeResponse(res, &async)
+	return async, err
+}
+
+// deleteAsyncSearch deletes a stored async search.
+// The request context might be cancelled already, hence the search is deleted using a separate context.
+func (r *elasticsearchREST) deleteAsyncSearch(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), asyncSearchDeleteTimeout)
+	defer cancel()
+
+	res, err := r.es.AsyncSearch.Delete(id, r.es.AsyncSearch.Delete.WithContext(ctx))
+	if err != nil {
+		klog.ErrorS(err, "failed to delete async search", "id", id)
+		return
+	}
+
+	res.Body.Close()
+}
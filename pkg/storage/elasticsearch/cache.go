@@ -0,0 +1,70 @@
+package elasticsearch
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// cacheKey returns the key of a query result within the query cache.
+// The key consists of the queried indices, the namespace scope of the request, the limit and the encoded query.
+// Query maps are encoded with sorted keys, hence equal queries result in the same key.
+// Only queries whose time range is bounded by absolute timestamps and ends in the past are cacheable.
+func (r *elasticsearchREST) cacheKey(ctx context.Context, query []byte, options *metainternalversion.ListOptions) (string, bool) {
+	if r.cache == nil {
+		return "", false
+	}
+
+	now := time.Now()
+	requirements := r.requirements(options)
+	_, to, err := r.timeRange(requirements, now)
+	if err != nil || !to.Before(now) || !r.absoluteTimeRange(requirements) {
+		return "", false
+	}
+
+	ns, _ := request.NamespaceFrom(ctx)
+	return strings.Join([]string{
+		strings.Join(r.indices(ctx, options), ","),
+		ns,
+		strconv.FormatInt(options.Limit, 10),
+		string(query),
+	}, "|"), true
+}
+
+// absoluteTimeRange returns true if the timestamp fields are bounded by absolute timestamps only.
+// Date math relative to now, including the default time range, is evaluated by elasticsearch on every request
+// and the results of such queries move with time.
+func (r *elasticsearchREST) absoluteTimeRange(requirements labels.Requirements) bool {
+	if !r.hasLowerBound(requirements) {
+		return false
+	}
+
+	for _, req := range requirements {
+		if !r.isTimestampField(req.Key()) {
+			continue
+		}
+
+		for _, value := range req.Values().UnsortedList() {
+			if strings.HasPrefix(value, "now") {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// cached returns the cached result of a query
+func (r *elasticsearchREST) cached(key string) (esResults, bool) {
+	v, ok := r.cache.Get(key)
+	if !ok {
+		return esResults{}, false
+	}
+
+	return v.(esResults), true
+}
@@ -0,0 +1,154 @@
+package elasticsearch
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	elasticsearch "github.com/elastic/go-elasticsearch/v8"
+
+	configv1alpha1 "github.com/raffis/kjournal/pkg/apis/config/v1alpha1"
+)
+
+const (
+	defaultMaxIdleConnsPerHost = 100
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultConnectTimeout      = 10 * time.Second
+	defaultKeepAlive           = 30 * time.Second
+	defaultMaxRetries          = 3
+	defaultRetryBackoff        = 100 * time.Millisecond
+)
+
+// retryOnStatus are the response codes of failed requests which are retried
+var retryOnStatus = []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+var (
+	clientsMu sync.Mutex
+	clients   = make(map[string]*elasticsearch.Client)
+)
+
+// getESClient returns the client of the backend.
+// The apiserver serves a single backend, its client and connection pool is shared between all apis
+// including the ones bound from LogSources instead of creating a new pool per api.
+func getESClient(backend *configv1alpha1.Backend) (*elasticsearch.Client, error) {
+	key, err := json.Marshal(backend.Elasticsearch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to encode backend", err)
+	}
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+
+	if es, ok := clients[string(key)]; ok {
+		return es, nil
+	}
+
+	es, err := newESClient(backend.Elasticsearch)
+	if err != nil {
+		return nil, err
+	}
+
+	clients[string(key)] = es
+	return es, nil
+}
+
+func newESClient(backend *configv1alpha1.BackendElasticsearch) (*elasticsearch.Client, error) {
+	var cert []byte
+	if backend.TLS.CACert != "" {
+		c, err := os.ReadFile(backend.TLS.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to load cacert", err)
+		}
+
+		cert = c
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed create cert pool", err)
+	}
+
+	if len(cert) > 0 {
+		pool.AppendCertsFromPEM(cert)
+	}
+
+	conn := backend.Connection
+	connectTimeout := conn.ConnectTimeout.Duration
+	if connectTimeout == 0 {
+		connectTimeout = defaultConnectTimeout
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   connectTimeout,
+			KeepAlive: defaultKeepAlive,
+		}).DialContext,
+		TLSHandshakeTimeout:   connectTimeout,
+		MaxConnsPerHost:       conn.MaxConnsPerHost,
+		MaxIdleConnsPerHost:   defaultMaxIdleConnsPerHost,
+		IdleConnTimeout:       defaultIdleConnTimeout,
+		ResponseHeaderTimeout: conn.RequestTimeout.Duration,
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: backend.TLS.AllowInsecure,
+			RootCAs:            pool,
+			ServerName:         backend.TLS.ServerName,
+		},
+	}
+
+	if conn.MaxIdleConnsPerHost != 0 {
+		transport.MaxIdleConnsPerHost = conn.MaxIdleConnsPerHost
+	}
+	if conn.IdleConnTimeout.Duration != 0 {
+		transport.IdleConnTimeout = conn.IdleConnTimeout.Duration
+	}
+
+	cluster := &cluster{}
+	cfg := elasticsearch.Config{
+		Addresses:             backend.URL,
+		Transport:             &compatTransport{RoundTripper: &traceTransport{RoundTripper: transport}, cluster: cluster},
+		Logger:                &logger{},
+		CompressRequestBody:   conn.Compression,
+		RetryOnStatus:         retryOnStatus,
+		MaxRetries:            defaultMaxRetries,
+		RetryBackoff:          retryBackoff(conn.RetryBackoff.Duration),
+		DiscoverNodesOnStart:  conn.DiscoverNodesOnStart,
+		DiscoverNodesInterval: conn.DiscoverNodesInterval.Duration,
+	}
+
+	switch {
+	case conn.MaxRetries < 0:
+		cfg.DisableRetry = true
+	case conn.MaxRetries > 0:
+		cfg.MaxRetries = conn.MaxRetries
+	}
+
+	es, err := elasticsearch.NewClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to create elasticsearch client", err)
+	}
+
+	cluster.es = es
+	clustersMu.Lock()
+	clusters[es] = cluster
+	clustersMu.Unlock()
+
+	return es, nil
+}
+
+// retryBackoff returns an exponential backoff starting at the given duration
+func retryBackoff(initial time.Duration) func(attempt int) time.Duration {
+	if initial == 0 {
+		initial = defaultRetryBackoff
+	}
+
+	return func(attempt int) time.Duration {
+		return initial * time.Duration(1<<uint(attempt-1))
+	}
+}
@@ -0,0 +1,275 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	elasticsearch "github.com/elastic/go-elasticsearch/v8"
+	"k8s.io/apiserver/pkg/warning"
+	"k8s.io/klog/v2"
+)
+
+const (
+	tierCold   = "cold"
+	tierFrozen = "frozen"
+
+	// dataStreamMetadataTTL is the duration the backing index metadata of a data stream is cached
+	dataStreamMetadataTTL = time.Minute
+
+	settingCreationDate   = "index.creation_date"
+	settingTierPreference = "index.routing.allocation.include._tier_preference"
+	settingStoreType      = "index.store.type"
+)
+
+// backingIndex is a backing index of a data stream
+type backingIndex struct {
+	Name         string
+	CreationDate time.Time
+
+	// RolloverDate is the creation date of the next backing index, it is zero for the write index
+	RolloverDate time.Time
+
+	// Tier is the preferred data tier of the index, e.g. hot or frozen
+	Tier string
+
+	// SearchableSnapshot is true if the index is a mounted searchable snapshot
+	SearchableSnapshot bool
+}
+
+// dataStreams holds the data streams of an api by their name.
+// An api might be routed to several data streams by the namespace or date of a request.
+type dataStreams struct {
+	es      *elasticsearch.Client
+	mu      sync.Mutex
+	streams map[string]*dataStream
+}
+
+func newDataStreams(es *elasticsearch.Client) *dataStreams {
+	return &dataStreams{
+		es:      es,
+		streams: make(map[string]*dataStream),
+	}
+}
+
+// get returns the data stream with the given name
+func (d *dataStreams) get(name string) *dataStream {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stream, ok := d.streams[name]
+	if !ok {
+		stream = newDataStream(d.es, name)
+		d.streams[name] = stream
+	}
+
+	return stream
+}
+
+// cachedIndices returns the cached backing indices of all data streams, including expired ones
+func (d *dataStreams) cachedIndices() []backingIndex {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var indices []backingIndex
+	for _, stream := range d.streams {
+		stream.mu.Lock()
+		indices = append(indices, stream.indices...)
+		stream.mu.Unlock()
+	}
+
+	return indices
+}
+
+// dataStream resolves the backing indices of a data stream.
+// The metadata of the backing indices is cached as it only changes on rollover or on ilm phase transitions.
+type dataStream struct {
+	es      *elasticsearch.Client
+	name    string
+	mu      sync.Mutex
+	indices []backingIndex
+	expires time.Time
+}
+
+func newDataStream(es *elasticsearch.Client, name string) *dataStream {
+	return &dataStream{
+		es:   es,
+		name: name,
+	}
+}
+
+// backingIndices returns the backing indices of the data stream ordered by their creation date
+func (d *dataStream) backingIndices(ctx context.Context) ([]backingIndex, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.indices != nil && time.Now().Before(d.expires) {
+		return d.indices, nil
+	}
+
+	indices, err := d.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	d.indices = indices
+	d.expires = time.Now().Add(dataStreamMetadataTTL)
+	return indices, nil
+}
+
+func (d *dataStream) load(ctx context.Context) ([]backingIndex, error) {
+	res, err := d.es.Indices.GetSettings(
+		d.es.Indices.GetSettings.WithContext(ctx),
+		d.es.Indices.GetSettings.WithIndex(d.name),
+		d.es.Indices.GetSettings.WithName(settingCreationDate, settingTierPreference, settingStoreType),
+		d.es.Indices.GetSettings.WithFlatSettings(true),
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get backing indices of data stream %s", err, d.name)
+	}
+
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("getting backing indices of data stream %s failed with status %s", d.name, res.Status())
+	}
+
+	var settings map[string]struct {
+		Settings map[string]string `json:"settings"`
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&settings); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode backing indices of data stream %s", err, d.name)
+	}
+
+	indices := make([]backingIndex, 0, len(settings))
+	for name, index := range settings {
+		ms, err := strconv.ParseInt(index.Settings[settingCreationDate], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid creation date of backing index %s", err, name)
+		}
+
+		indices = append(indices, backingIndex{
+			Name:               name,
+			CreationDate:       time.UnixMilli(ms),
+			Tier:               preferredTier(index.Settings[settingTierPreference]),
+			SearchableSnapshot: index.Settings[settingStoreType] == "snapshot",
+		})
+	}
+
+	sort.Slice(indices, func(i, j int) bool {
+		return indices[i].CreationDate.Before(indices[j].CreationDate)
+	})
+
+	for i := 0; i < len(indices)-1; i++ {
+		indices[i].RolloverDate = indices[i+1].CreationDate
+	}
+
+	return indices, nil
+}
+
+// preferredTier returns the first tier of a tier preference like data_cold,data_warm,data_hot
+func preferredTier(preference string) string {
+	tier := strings.Split(preference, ",")[0]
+	return strings.TrimPrefix(strings.TrimSpace(tier), "data_")
+}
+
+// overlappingIndices returns the backing indices which may hold documents within the time range.
+// Documents are written to the write index at the time they arrive, hence a backing index can not hold any
+// documents newer than its rollover date. Late arriving documents might be older than the creation date though.
+func overlappingIndices(indices []backingIndex, from time.Time) []backingIndex {
+	var overlapping []backingIndex
+	for _, index := range indices {
+		if from.IsZero() || index.RolloverDate.IsZero() || index.RolloverDate.After(from) {
+			overlapping = append(overlapping, index)
+		}
+	}
+
+	return overlapping
+}
+
+// dataStreamIndices returns the backing indices of the data streams which overlap the time range.
+// The data streams are routed by namespace, date templates and clusters the same way as index patterns.
+func (r *elasticsearchREST) dataStreamIndices(ctx context.Context, from, to time.Time) []string {
+	var indices []string
+	for _, pattern := range r.indexPatterns(ctx) {
+		for _, resolved := range dateIndices(pattern, from, to) {
+			for _, name := range strings.Split(resolved, ",") {
+				indices = append(indices, r.backingIndexNames(ctx, name, from)...)
+			}
+		}
+	}
+
+	return indices
+}
+
+// backingIndexNames returns the backing indices of a data stream which overlap the time range.
+// Backing indices are only resolved for data streams of the local cluster, wildcards and data streams of remote clusters are queried by their name.
+// The data stream itself is queried if its backing indices can not be resolved or if more than maxDateIndices backing indices
+// overlap the time range as the index list is part of the request url.
+func (r *elasticsearchREST) backingIndexNames(ctx context.Context, name string, from time.Time) []string {
+	if strings.ContainsAny(name, "*:") {
+		return []string{name}
+	}
+
+	indices, err := r.dataStreams.get(name).backingIndices(ctx)
+	if err != nil {
+		klog.ErrorS(err, "failed to resolve backing indices, query the data stream instead", "dataStream", name)
+		return []string{name}
+	}
+
+	var names []string
+	for _, index := range overlappingIndices(indices, from) {
+		names = append(names, index.Name)
+	}
+
+	if len(names) == 0 || len(names) > maxDateIndices {
+		return []string{name}
+	}
+
+	return names
+}
+
+// inspectIndices looks up the metadata of the queried backing indices.
+// It adds a warning to the response if any searchable snapshots are queried and returns
+// true if the search should be routed through the async search api.
+func (r *elasticsearchREST) inspectIndices(ctx context.Context, names []string) (async bool) {
+	if r.dataStreams == nil {
+		return false
+	}
+
+	indices := r.dataStreams.cachedIndices()
+	queried := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		queried[name] = struct{}{}
+	}
+
+	var snapshots []string
+	for _, index := range indices {
+		if _, ok := queried[index.Name]; !ok {
+			continue
+		}
+
+		if index.SearchableSnapshot {
+			snapshots = append(snapshots, index.Name)
+		}
+
+		for _, tier := range r.opts.Backend.AsyncSearch.Tiers {
+			if index.Tier == tier {
+				async = true
+			}
+		}
+	}
+
+	if len(snapshots) > 0 {
+		warning.AddWarning(ctx, "", fmt.Sprintf("the query includes searchable snapshots (%s), narrow the time range for faster responses", strings.Join(snapshots, ", ")))
+	}
+
+	return async
+}
@@ -0,0 +1,92 @@
+package elasticsearch
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxDateIndices is the maximum number of indices resolved from a date template.
+// Longer time ranges fall back to a wildcard as the index list is part of the request url.
+const maxDateIndices = 100
+
+// dateTemplate matches date templates within index patterns like {yyyy.MM.dd}
+var dateTemplate = regexp.MustCompile(`\{[yMdH._\-]+\}`)
+
+// dateLayouts maps the supported date template tokens to go time layouts
+var dateLayouts = strings.NewReplacer(
+	"yyyy", "2006",
+	"yy", "06",
+	"MM", "01",
+	"dd", "02",
+	"HH", "15",
+)
+
+// hasDateTemplate returns true if the index pattern contains a date template
+func hasDateTemplate(pattern string) bool {
+	return dateTemplate.MatchString(pattern)
+}
+
+// wildcardDateTemplate replaces all date templates in the index pattern with a wildcard
+func wildcardDateTemplate(pattern string) string {
+	return dateTemplate.ReplaceAllString(pattern, "*")
+}
+
+// dateIndices resolves the date templates of an index pattern to the indices overlapping the time range.
+// The pattern is returned as wildcard if the range has no lower bound or spans more than maxDateIndices indices.
+func dateIndices(pattern string, from, to time.Time) []string {
+	if !hasDateTemplate(pattern) {
+		return []string{pattern}
+	}
+
+	if from.IsZero() || to.Before(from) {
+		return []string{wildcardDateTemplate(pattern)}
+	}
+
+	truncate, next := dateInterval(pattern)
+	var indices []string
+
+	for ts := truncate(from.UTC()); !ts.After(to.UTC()); ts = next(ts) {
+		if len(indices) == maxDateIndices {
+			return []string{wildcardDateTemplate(pattern)}
+		}
+
+		indices = append(indices, dateTemplate.ReplaceAllStringFunc(pattern, func(template string) string {
+			return ts.Format(dateLayouts.Replace(strings.Trim(template, "{}")))
+		}))
+	}
+
+	return indices
+}
+
+// dateInterval returns the interval of the indices derived from the finest unit used in the date templates
+func dateInterval(pattern string) (truncate func(time.Time) time.Time, next func(time.Time) time.Time) {
+	templates := strings.Join(dateTemplate.FindAllString(pattern, -1), "")
+
+	switch {
+	case strings.Contains(templates, "HH"):
+		return func(ts time.Time) time.Time {
+				return ts.Truncate(time.Hour)
+			}, func(ts time.Time) time.Time {
+				return ts.Add(time.Hour)
+			}
+	case strings.Contains(templates, "dd"):
+		return func(ts time.Time) time.Time {
+				return time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, time.UTC)
+			}, func(ts time.Time) time.Time {
+				return ts.AddDate(0, 0, 1)
+			}
+	case strings.Contains(templates, "MM"):
+		return func(ts time.Time) time.Time {
+				return time.Date(ts.Year(), ts.Month(), 1, 0, 0, 0, 0, time.UTC)
+			}, func(ts time.Time) time.Time {
+				return ts.AddDate(0, 1, 0)
+			}
+	default:
+		return func(ts time.Time) time.Time {
+				return time.Date(ts.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+			}, func(ts time.Time) time.Time {
+				return ts.AddDate(1, 0, 0)
+			}
+	}
+}
@@ -4,7 +4,7 @@ import "encoding/json"
 
 type esHit struct {
 	Index   string          `json:"_index"`
-	DocType string          `json:"_type"`
+	DocType string          `json:"_type"` // only returned by elasticsearch 7, mapping types were removed in 8
 	ID      string          `json:"_id"`
 	Sort    []interface{}   `json:"sort"`
 	Score   float64         `json:"_score"`
@@ -0,0 +1,177 @@
+package elasticsearch
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+const (
+	namespacePlaceholder = "{namespace}"
+
+	// localCluster is the alias of the local cluster within the clusters of an api binding
+	localCluster = "local"
+
+	// annotationESCluster holds the elasticsearch cluster an object was read from, either the alias of a remote cluster or the name of the local cluster.
+	// It is not related to the kubernetes cluster of the cluster selector.
+	annotationESCluster = "kjournal.io/es-cluster"
+)
+
+// indices returns the index patterns which are queried for the request.
+// Namespaced requests are routed to the index of the namespace, either from the namespace index map
+// or by replacing the {namespace} placeholder in the index pattern.
+// Requests across all namespaces query the indices of all namespaces.
+// Date templates within the index patterns are resolved to the indices overlapping the requested time range.
+// If a data stream is configured it is routed the same way and its backing indices overlapping the requested time range are queried.
+func (r *elasticsearchREST) indices(ctx context.Context, options *metainternalversion.ListOptions) []string {
+	from, to, err := r.timeRange(r.requirements(options), time.Now())
+	if err != nil {
+		from = time.Time{}
+	}
+
+	if r.dataStreams != nil {
+		return r.dataStreamIndices(ctx, from, to)
+	}
+
+	var indices []string
+	for _, pattern := range r.indexPatterns(ctx) {
+		indices = append(indices, dateIndices(pattern, from, to)...)
+	}
+
+	return indices
+}
+
+// indexPatterns returns the index patterns of the request before date templates are resolved.
+// If clusters are configured the patterns are prefixed with their aliases.
+func (r *elasticsearchREST) indexPatterns(ctx context.Context) []string {
+	if !r.isNamespaced {
+		return clusterIndexPatterns([]string{r.opts.Backend.pattern()}, r.opts.Backend.Clusters)
+	}
+
+	ns, _ := request.NamespaceFrom(ctx)
+	if ns != "" {
+		if index, ok := r.opts.Backend.NamespaceIndex[ns]; ok {
+			return clusterIndexPatterns([]string{index}, r.opts.Backend.Clusters)
+		}
+
+		return clusterIndexPatterns([]string{strings.ReplaceAll(r.opts.Backend.pattern(), namespacePlaceholder, ns)}, r.opts.Backend.Clusters)
+	}
+
+	indices := []string{strings.ReplaceAll(r.opts.Backend.pattern(), namespacePlaceholder, "*")}
+	for _, index := range r.opts.Backend.NamespaceIndex {
+		indices = append(indices, index)
+	}
+
+	sort.Strings(indices[1:])
+	return clusterIndexPatterns(indices, r.opts.Backend.Clusters)
+}
+
+// validateNamespaceIndex rejects index patterns where the {namespace} placeholder is followed by a wildcard
+// without an unambiguous delimiter. Namespace names consist of lower case alphanumeric characters and dashes,
+// hence the pattern logs-{namespace}-* of the namespace team would also match the indices of the namespace team-b.
+// The placeholder must either be followed by a character which is not valid within namespace names (e.g. . or _)
+// or by a concrete suffix without wildcards. Date templates are treated as wildcards as they are widened if the time range is unbounded.
+func validateNamespaceIndex(index string) error {
+	for _, pattern := range strings.Split(index, ",") {
+		i := strings.Index(pattern, namespacePlaceholder)
+		if i == -1 {
+			continue
+		}
+
+		suffix := pattern[i+len(namespacePlaceholder):]
+		if !strings.Contains(wildcardDateTemplate(suffix), "*") {
+			continue
+		}
+
+		if strings.ContainsAny(suffix[:1], "abcdefghijklmnopqrstuvwxyz0123456789-*{") {
+			return fmt.Errorf("ambiguous index pattern %s, the %s placeholder followed by a wildcard must be delimited by a character which is not valid within namespace names (e.g. . or _)", pattern, namespacePlaceholder)
+		}
+	}
+
+	return nil
+}
+
+// clusterIndexPatterns prefixes each index pattern with the cluster aliases to search them using cross cluster search
+func clusterIndexPatterns(patterns []string, clusters []string) []string {
+	if len(clusters) == 0 {
+		return patterns
+	}
+
+	var result []string
+	for _, pattern := range patterns {
+		var prefixed []string
+		for _, cluster := range clusters {
+			for _, index := range strings.Split(pattern, ",") {
+				if cluster == localCluster {
+					prefixed = append(prefixed, index)
+				} else {
+					prefixed = append(prefixed, cluster+":"+index)
+				}
+			}
+		}
+
+		result = append(result, strings.Join(prefixed, ","))
+	}
+
+	return result
+}
+
+// crossCluster returns true if indices of remote clusters are searched
+func (r *elasticsearchREST) crossCluster() bool {
+	for _, cluster := range r.opts.Backend.Clusters {
+		if cluster != localCluster {
+			return true
+		}
+	}
+
+	if strings.Contains(r.opts.Backend.pattern(), ":") {
+		return true
+	}
+
+	for _, index := range r.opts.Backend.NamespaceIndex {
+		if strings.Contains(index, ":") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clusterName returns the alias of the remote cluster a hit was read from or the name of the local cluster
+func (r *elasticsearchREST) clusterName(index string) string {
+	if i := strings.Index(index, ":"); i > 0 {
+		return index[:i]
+	}
+
+	return r.cluster.name()
+}
+
+// namespaceRouting returns true if namespaced requests are routed to per namespace indices
+func (r *elasticsearchREST) namespaceRouting() bool {
+	return r.isNamespaced && (len(r.opts.Backend.NamespaceIndex) > 0 || strings.Contains(r.opts.Backend.pattern(), namespacePlaceholder))
+}
+
+// ignoreUnavailable returns true if some of the queried indices might not exist
+func (r *elasticsearchREST) ignoreUnavailable() bool {
+	return r.namespaceRouting() || r.dateRouting() || r.dataStreams != nil
+}
+
+// dateRouting returns true if requests are routed to indices resolved from date templates
+func (r *elasticsearchREST) dateRouting() bool {
+	if hasDateTemplate(r.opts.Backend.pattern()) {
+		return true
+	}
+
+	for _, index := range r.opts.Backend.NamespaceIndex {
+		if hasDateTemplate(index) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,95 @@
+package elasticsearch
+
+import (
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+
+	"github.com/raffis/kjournal/pkg/storage"
+)
+
+const backendName = "elasticsearch"
+
+var (
+	// searchTook tracks the time elasticsearch reports to have spent on a search request
+	searchTook = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      "kjournal",
+			Subsystem:      "elasticsearch",
+			Name:           "search_took_seconds",
+			Help:           "Time elasticsearch spent executing search requests as reported by took.",
+			Buckets:        []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource"},
+	)
+
+	// searchTimedOut counts search requests which timed out in elasticsearch
+	searchTimedOut = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "kjournal",
+			Subsystem:      "elasticsearch",
+			Name:           "search_timed_out_total",
+			Help:           "Number of search requests which timed out and returned partial results.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource"},
+	)
+
+	// searchShardFailures counts failed shards of search requests
+	searchShardFailures = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "kjournal",
+			Subsystem:      "elasticsearch",
+			Name:           "search_shard_failures_total",
+			Help:           "Number of shards which failed during search requests.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource"},
+	)
+
+	// queryCacheHits counts queries which were answered from the query cache
+	queryCacheHits = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "kjournal",
+			Subsystem:      "elasticsearch",
+			Name:           "query_cache_hits_total",
+			Help:           "Number of cacheable queries which were answered from the query cache.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource"},
+	)
+
+	// queryCacheMisses counts cacheable queries which were not found in the query cache
+	queryCacheMisses = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "kjournal",
+			Subsystem:      "elasticsearch",
+			Name:           "query_cache_misses_total",
+			Help:           "Number of cacheable queries which were not found in the query cache.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource"},
+	)
+)
+
+func init() {
+	legacyregistry.MustRegister(searchTook)
+	legacyregistry.MustRegister(searchTimedOut)
+	legacyregistry.MustRegister(searchShardFailures)
+	legacyregistry.MustRegister(queryCacheHits)
+	legacyregistry.MustRegister(queryCacheMisses)
+}
+
+func (r *elasticsearchREST) observeSearch(results esResults) {
+	resource := r.groupResource.Resource
+	searchTook.WithLabelValues(resource).Observe(float64(results.Took) / 1000)
+	storage.QueryHits.WithLabelValues(resource, backendName).Observe(float64(len(results.Hits.Hits)))
+
+	if results.TimedOut {
+		searchTimedOut.WithLabelValues(resource).Inc()
+	}
+
+	if results.Shards.Failed > 0 {
+		searchShardFailures.WithLabelValues(resource).Add(float64(results.Shards.Failed))
+	}
+}
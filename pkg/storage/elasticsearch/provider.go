@@ -17,11 +17,10 @@ limitations under the License.
 package elasticsearch
 
 import (
-	"crypto/tls"
-	"crypto/x509"
+	"context"
+	"encoding/json"
 	"fmt"
-	"net/http"
-	"os"
+	"strings"
 	"time"
 
 	"k8s.io/apimachinery/pkg/labels"
@@ -34,60 +33,102 @@ import (
 	"sigs.k8s.io/apiserver-runtime/pkg/builder/resource"
 
 	// +kubebuilder:scaffold:resource-imports
-	elasticsearch "github.com/elastic/go-elasticsearch/v8"
 	configv1alpha1 "github.com/raffis/kjournal/pkg/apis/config/v1alpha1"
 	"github.com/raffis/kjournal/pkg/storage"
 )
 
 func init() {
 	storage.Providers.MustRegister("elasticsearch", newElasticsearchStorageProvider)
+	storage.Checks.MustRegister("elasticsearch", checkElasticsearch)
+	storage.BindingChecks.MustRegister("elasticsearch", checkElasticsearchIndex)
 }
 
-var esClient *elasticsearch.Client
+func checkElasticsearch(ctx context.Context, backend *configv1alpha1.Backend) error {
+	client, err := getESClient(backend)
+	if err != nil {
+		return err
+	}
 
-func getESClient(backend *configv1alpha1.Backend) (*elasticsearch.Client, error) {
-	if esClient != nil {
-		return esClient, nil
+	version, err := getCluster(client).Version(ctx)
+	if err != nil {
+		return err
 	}
 
-	var cert []byte
-	if backend.Elasticsearch.TLS.CACert != "" {
-		c, err := os.ReadFile(backend.Elasticsearch.TLS.CACert)
-		if err != nil {
-			return nil, fmt.Errorf("%w: failed to load cacert", err)
-		}
+	if err := version.supported(); err != nil {
+		return err
+	}
 
-		cert = c
+	res, err := client.Cluster.Health(client.Cluster.Health.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("%w: failed to request elasticsearch cluster health", err)
 	}
 
-	pool, err := x509.SystemCertPool()
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("elasticsearch cluster health failed with status %s", res.Status())
+	}
+
+	var health struct {
+		Status string `json:"status"`
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&health); err != nil {
+		return fmt.Errorf("%w: failed to decode elasticsearch cluster health", err)
+	}
+
+	if health.Status == "red" {
+		return fmt.Errorf("elasticsearch cluster health is %s", health.Status)
+	}
+
+	return nil
+}
+
+// checkElasticsearchIndex verifies that the index patterns of an api binding match any indices, aliases or data streams
+func checkElasticsearchIndex(ctx context.Context, backend *configv1alpha1.Backend, apiBinding *configv1alpha1.API) error {
+	client, err := getESClient(backend)
 	if err != nil {
-		return nil, fmt.Errorf("%w: failed create cert pool", err)
+		return err
 	}
 
-	if len(cert) > 0 {
-		pool.AppendCertsFromPEM(cert)
+	opts, err := MakeOptionsFromConfig(apiBinding)
+	if err != nil {
+		return err
 	}
 
-	cfg := elasticsearch.Config{
-		Addresses: backend.Elasticsearch.URL,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: backend.Elasticsearch.TLS.AllowInsecure,
-				RootCAs:            pool,
-				ServerName:         backend.Elasticsearch.TLS.ServerName,
-			},
-		},
-		Logger: &logger{},
+	patterns := []string{wildcardDateTemplate(strings.ReplaceAll(opts.Backend.pattern(), namespacePlaceholder, "*"))}
+	for _, index := range opts.Backend.NamespaceIndex {
+		patterns = append(patterns, wildcardDateTemplate(index))
 	}
 
-	es, err := elasticsearch.NewClient(cfg)
+	patterns = clusterIndexPatterns(patterns, opts.Backend.Clusters)
+
+	res, err := client.Indices.ResolveIndex(patterns, client.Indices.ResolveIndex.WithContext(ctx))
 	if err != nil {
-		return nil, fmt.Errorf("%w: failed to create elasticsearch client", err)
+		return fmt.Errorf("%w: failed to resolve index", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("resolving index %s failed with status %s", strings.Join(patterns, ","), res.Status())
+	}
+
+	var resolved struct {
+		Indices     []interface{} `json:"indices"`
+		Aliases     []interface{} `json:"aliases"`
+		DataStreams []interface{} `json:"data_streams"`
 	}
 
-	esClient = es
-	return es, nil
+	if err := json.NewDecoder(res.Body).Decode(&resolved); err != nil {
+		return fmt.Errorf("%w: failed to decode resolved index", err)
+	}
+
+	if len(resolved.Indices)+len(resolved.Aliases)+len(resolved.DataStreams) == 0 {
+		return fmt.Errorf("index %s does not match any indices", strings.Join(patterns, ","))
+	}
+
+	return nil
 }
 
 func MakeDefaultOptions() Options {
@@ -97,6 +138,11 @@ func MakeDefaultOptions() Options {
 			RefreshRate:     time.Millisecond * 500,
 			TimestampFields: []string{"@timestamp"},
 			BulkSize:        1000,
+			CacheTTL:        5 * time.Minute,
+			AsyncSearch: OptionsAsyncSearch{
+				WaitForCompletionTimeout: 5 * time.Second,
+				KeepAlive:                5 * time.Minute,
+			},
 		},
 		DefaultTimeRange: "now-24h",
 	}
@@ -107,20 +153,48 @@ type Options struct {
 	DropFields       []string
 	Filter           labels.Requirements
 	DefaultTimeRange string
+	Columns          []configv1alpha1.Column
+	RedactQuery      bool
+	Redactor         *storage.Redactor
+	ClusterField     string
+	DefaultCluster   string
 	Backend          OptionsBackend
 }
 
 type OptionsBackend struct {
 	Index           string
+	NamespaceIndex  map[string]string
 	RefreshRate     time.Duration
 	TimestampFields []string
 	BulkSize        int64
+	CacheSize       int
+	CacheTTL        time.Duration
+	SearchTimeout   time.Duration
+	DataStream      string
+	AsyncSearch     OptionsAsyncSearch
+	Clusters        []string
+}
+
+// pattern returns the configured data stream or index pattern the requests are routed to
+func (b OptionsBackend) pattern() string {
+	if b.DataStream != "" {
+		return b.DataStream
+	}
+
+	return b.Index
+}
+
+type OptionsAsyncSearch struct {
+	Tiers                    []string
+	WaitForCompletionTimeout time.Duration
+	KeepAlive                time.Duration
 }
 
 func MakeOptionsFromConfig(apiBinding *configv1alpha1.API) (Options, error) {
 	options := MakeDefaultOptions()
 	options.FieldMap = apiBinding.FieldMap
 	options.DropFields = apiBinding.DropFields
+	options.Columns = apiBinding.Columns
 
 	req, err := labels.ParseToRequirements(apiBinding.Filter)
 	if err != nil {
@@ -128,10 +202,19 @@ func MakeOptionsFromConfig(apiBinding *configv1alpha1.API) (Options, error) {
 	}
 
 	options.Filter = req
+	options.ClusterField = apiBinding.ClusterField
+	options.DefaultCluster = apiBinding.DefaultCluster
 
 	if apiBinding.Backend.Elasticsearch.Index != "" {
+		if err := validateNamespaceIndex(apiBinding.Backend.Elasticsearch.Index); err != nil {
+			return options, err
+		}
+
 		options.Backend.Index = apiBinding.Backend.Elasticsearch.Index
 	}
+	if apiBinding.Backend.Elasticsearch.NamespaceIndex != nil {
+		options.Backend.NamespaceIndex = apiBinding.Backend.Elasticsearch.NamespaceIndex
+	}
 	if apiBinding.Backend.Elasticsearch.RefreshRate.Duration != 0 {
 		options.Backend.RefreshRate = apiBinding.Backend.Elasticsearch.RefreshRate.Duration
 	}
@@ -141,6 +224,34 @@ func MakeOptionsFromConfig(apiBinding *configv1alpha1.API) (Options, error) {
 	if apiBinding.Backend.Elasticsearch.BulkSize != 0 {
 		options.Backend.BulkSize = apiBinding.Backend.Elasticsearch.BulkSize
 	}
+	if apiBinding.Backend.Elasticsearch.Cache != nil {
+		options.Backend.CacheSize = apiBinding.Backend.Elasticsearch.Cache.Size
+		if apiBinding.Backend.Elasticsearch.Cache.TTL.Duration != 0 {
+			options.Backend.CacheTTL = apiBinding.Backend.Elasticsearch.Cache.TTL.Duration
+		}
+	}
+	if apiBinding.Backend.Elasticsearch.DataStream != "" {
+		if err := validateNamespaceIndex(apiBinding.Backend.Elasticsearch.DataStream); err != nil {
+			return options, err
+		}
+
+		options.Backend.DataStream = apiBinding.Backend.Elasticsearch.DataStream
+	}
+	if apiBinding.Backend.Elasticsearch.AsyncSearch != nil {
+		options.Backend.AsyncSearch.Tiers = apiBinding.Backend.Elasticsearch.AsyncSearch.Tiers
+		if options.Backend.AsyncSearch.Tiers == nil {
+			options.Backend.AsyncSearch.Tiers = []string{tierCold, tierFrozen}
+		}
+		if apiBinding.Backend.Elasticsearch.AsyncSearch.WaitForCompletionTimeout.Duration != 0 {
+			options.Backend.AsyncSearch.WaitForCompletionTimeout = apiBinding.Backend.Elasticsearch.AsyncSearch.WaitForCompletionTimeout.Duration
+		}
+		if apiBinding.Backend.Elasticsearch.AsyncSearch.KeepAlive.Duration != 0 {
+			options.Backend.AsyncSearch.KeepAlive = apiBinding.Backend.Elasticsearch.AsyncSearch.KeepAlive.Duration
+		}
+	}
+	if apiBinding.Backend.Elasticsearch.Clusters != nil {
+		options.Backend.Clusters = apiBinding.Backend.Elasticsearch.Clusters
+	}
 	if apiBinding.DefaultTimeRange != "" {
 		options.DefaultTimeRange = apiBinding.DefaultTimeRange
 	}
@@ -172,6 +283,13 @@ func newElasticsearchStorageProvider(obj resource.Object, scheme *runtime.Scheme
 		return nil, err
 	}
 
+	opts.RedactQuery = backend.Elasticsearch.Tracing.RedactQuery
+	opts.Backend.SearchTimeout = backend.Elasticsearch.Connection.SearchTimeout.Duration
+	opts.Redactor, err = storage.NewRedactor(gr, apiBinding.Redactions, apiBinding.FieldMap)
+	if err != nil {
+		return nil, err
+	}
+
 	return NewElasticsearchREST(
 		gr,
 		codec,
@@ -6,10 +6,14 @@ import (
 	"fmt"
 	"strings"
 
+	"go.opentelemetry.io/otel/attribute"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/raffis/kjournal/pkg/storage"
 )
 
 var operatorMap = map[selection.Operator][]string{
@@ -20,8 +24,24 @@ var operatorMap = map[selection.Operator][]string{
 	selection.LessThan:     {"must", "range"},
 	selection.DoesNotExist: {"must_not", "exists"},
 	selection.Exists:       {"must", "exists"},
+	selection.In:           {"must", "match_phrase"},
+	selection.NotIn:        {"must_not", "match_phrase"},
 }
 
+const (
+	// clusterSelector selects the kubernetes clusters of the stored documents
+	clusterSelector = "cluster"
+
+	// sortOrderSelector selects the sort order of the results, either asc or desc
+	sortOrderSelector = "sortOrder"
+
+	// sortBySelector selects the field the results are sorted by instead of the timestamp fields
+	sortBySelector = "sortBy"
+
+	sortAsc  = "asc"
+	sortDesc = "desc"
+)
+
 type queryBuilderFunc func() error
 type queryBuilder struct {
 	ctx     context.Context
@@ -30,7 +50,16 @@ type queryBuilder struct {
 	query   map[string]interface{}
 }
 
-func queryFromListOptions(ctx context.Context, options *metainternalversion.ListOptions, rest *elasticsearchREST) (map[string]interface{}, error) {
+func queryFromListOptions(ctx context.Context, options *metainternalversion.ListOptions, rest *elasticsearchREST, redactions *storage.Redactions) (query map[string]interface{}, err error) {
+	_, span := startSpan(ctx, "elasticsearch.queryFromListOptions",
+		attribute.String("kjournal.resource", rest.groupResource.Resource),
+		attribute.String("kjournal.continue", options.Continue),
+	)
+
+	defer func() {
+		endSpan(span, err)
+	}()
+
 	q := queryBuilder{
 		rest:    rest,
 		ctx:     ctx,
@@ -50,14 +79,18 @@ func queryFromListOptions(ctx context.Context, options *metainternalversion.List
 	}
 
 	req, _ := options.LabelSelector.Requirements()
+	clusterReq, req := splitRequirements(req, clusterSelector)
+	sortReq, req := splitRequirements(req, sortOrderSelector, sortBySelector)
 
 	builders := []queryBuilderFunc{
+		func() error { return rest.redactedSelectors(options, redactions) },
 		q.continueToken,
-		q.sortByTimestampFields,
+		q.sort(sortReq),
 		q.fieldSelectors(req),
 		q.fieldSelectors(rest.opts.Filter),
 		q.defaultRange,
 		q.namespaceFilter,
+		q.clusterFilter(clusterReq),
 	}
 
 	for _, builder := range builders {
@@ -66,9 +99,31 @@ func queryFromListOptions(ctx context.Context, options *metainternalversion.List
 		}
 	}
 
+	b, err := json.Marshal(q.query)
+	if err != nil {
+		return q.query, err
+	}
+
+	span.SetAttributes(rest.queryAttribute(b))
 	return q.query, nil
 }
 
+// Query returns the indices and the translated backend query of the request, the query is redacted if configured.
+// Redactions do not alter the query, selectors on redacted fields are rejected by the request itself.
+func (r *elasticsearchREST) Query(ctx context.Context, options *metainternalversion.ListOptions) ([]string, string, error) {
+	query, err := queryFromListOptions(ctx, options, r, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	b, err := json.Marshal(query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return r.indices(ctx, options), r.queryString(b), nil
+}
+
 func (b *queryBuilder) fieldMapping(field string, defaultMap []string) []string {
 	if val, ok := b.rest.opts.FieldMap[field]; ok {
 		return val
@@ -92,28 +147,118 @@ func (b *queryBuilder) continueToken() error {
 	return nil
 }
 
-func (b *queryBuilder) sortByTimestampFields() error {
-	for _, tsField := range b.rest.opts.Backend.TimestampFields {
-		b.query["sort"] = append(b.query["sort"].([]map[string]interface{}), map[string]interface{}{
-			tsField: map[string]interface{}{
-				"order":         "asc",
-				"unmapped_type": "long",
-			},
-		})
+// sort orders the results by the field of the sortBy selector followed by the timestamp fields, the uid fields are used as tiebreaker.
+// The continue token holds the sort values of the last hit, it stays valid if the sort order is reversed to page backwards.
+func (b *queryBuilder) sort(requirements labels.Requirements) queryBuilderFunc {
+	return func() error {
+		order := sortAsc
+		var sortFields []string
+
+		for _, req := range requirements {
+			if (req.Operator() != selection.Equals && req.Operator() != selection.DoubleEquals) || req.Values().Len() != 1 {
+				return apierrors.NewBadRequest(fmt.Sprintf("%s selector requires exactly one value", req.Key()))
+			}
+
+			value := req.Values().List()[0]
+			switch req.Key() {
+			case sortOrderSelector:
+				if value != sortAsc && value != sortDesc {
+					return apierrors.NewBadRequest(fmt.Sprintf("invalid sort order %s, either %s or %s is supported", value, sortAsc, sortDesc))
+				}
+
+				order = value
+			case sortBySelector:
+				defaultMap := []string{value}
+				if value == "metadata.creationTimestamp" && len(b.rest.opts.Backend.TimestampFields) > 0 {
+					defaultMap = b.rest.opts.Backend.TimestampFields
+				}
+
+				sortFields = b.fieldMapping(value, defaultMap)
+			}
+		}
+
+		// Documents with equal values of the sortBy field are ordered by time, pages continue after them in a stable order
+		tiebreakers := append(append([]string{}, b.rest.opts.Backend.TimestampFields...), b.fieldMapping("metadata.uid", []string{})...)
+		for _, field := range tiebreakers {
+			if !containsKey(sortFields, field) {
+				sortFields = append(sortFields, field)
+			}
+		}
+
+		for _, field := range sortFields {
+			b.query["sort"] = append(b.query["sort"].([]map[string]interface{}), map[string]interface{}{
+				field: map[string]interface{}{
+					"order":         order,
+					"unmapped_type": "long",
+				},
+			})
+		}
+
+		return nil
 	}
+}
 
-	for _, uidField := range b.fieldMapping("metadata.uid", []string{}) {
-		b.query["sort"] = append(b.query["sort"].([]map[string]interface{}), map[string]interface{}{
-			uidField: map[string]interface{}{
-				"order":         "asc",
-				"unmapped_type": "long",
-			},
-		})
+// redactedSelectors rejects selectors and the sortBy selector on fields which are redacted for the requester
+// as they would allow to probe the redacted values
+func (r *elasticsearchREST) redactedSelectors(options *metainternalversion.ListOptions, redactions *storage.Redactions) error {
+	if redactions == nil || options.LabelSelector == nil {
+		return nil
+	}
+
+	requirements, _ := options.LabelSelector.Requirements()
+	for _, req := range requirements {
+		fields := []string{req.Key()}
+		switch req.Key() {
+		case clusterSelector, sortOrderSelector:
+			continue
+		case sortBySelector:
+			fields = req.Values().List()
+		}
+
+		for _, field := range fields {
+			if redactions.Redacted(field) {
+				return apierrors.NewForbidden(r.groupResource, "", fmt.Errorf("field %s is redacted, selecting it requires access to the %s subresource", field, storage.UnredactedSubresource))
+			}
+		}
 	}
 
 	return nil
 }
 
+// sortedByField returns true if the results of the request are sorted by another field than the timestamp
+func sortedByField(options *metainternalversion.ListOptions) bool {
+	if options.LabelSelector == nil {
+		return false
+	}
+
+	requirements, _ := options.LabelSelector.Requirements()
+	sortReq, _ := splitRequirements(requirements, sortBySelector)
+	for _, req := range sortReq {
+		if !req.Values().Has("metadata.creationTimestamp") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// descending returns true if the results of the request are sorted in descending order
+func descending(options *metainternalversion.ListOptions) bool {
+	if options.LabelSelector == nil {
+		return false
+	}
+
+	requirements, _ := options.LabelSelector.Requirements()
+	sortReq, _ := splitRequirements(requirements, sortOrderSelector)
+	for _, req := range sortReq {
+		if req.Values().Has(sortDesc) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (b *queryBuilder) fieldSelectors(requirements labels.Requirements) queryBuilderFunc {
 	return func() error {
 		for _, req := range requirements {
@@ -155,6 +300,16 @@ func (b *queryBuilder) fieldSelectors(requirements labels.Requirements) queryBui
 							},
 						},
 					}
+				case selection.In, selection.NotIn:
+					for _, value := range req.Values().List() {
+						should = append(should, map[string]interface{}{
+							operator[1]: map[string]interface{}{
+								fieldTo: value,
+							},
+						})
+					}
+
+					continue
 				case selection.Exists:
 				case selection.DoesNotExist:
 					shouldCondition = map[string]interface{}{
@@ -186,38 +341,22 @@ func (b *queryBuilder) fieldSelectors(requirements labels.Requirements) queryBui
 	}
 }
 
+// defaultRange bounds the timestamp fields by the default time range unless a selector sets a lower bound.
+// A selector with an upper bound only keeps the default lower bound, the same way TimeRange resolves the time range.
 func (b *queryBuilder) defaultRange() error {
-	var skipTimestampFilter bool
 	requirements, _ := b.options.LabelSelector.Requirements()
 
 	for _, req := range requirements {
-		operator, ok := operatorMap[req.Operator()]
-		if !ok {
-			return fmt.Errorf("invalid selector operator %s", operator)
+		if req.Key() == clusterSelector || req.Key() == sortOrderSelector || req.Key() == sortBySelector {
+			continue
 		}
 
-		fieldsMap := []string{req.Key()}
-
-		for field, fieldsTo := range b.rest.opts.FieldMap {
-			for k, fieldTo := range fieldsTo {
-				lookupKey := strings.TrimLeft(strings.Replace(req.Key(), field, fieldTo, -1), ".")
-				if lookupKey != req.Key() {
-					fieldsMap[k] = lookupKey
-					break
-				}
-			}
-		}
-
-		for _, fieldTo := range fieldsMap {
-			for _, tsField := range b.rest.opts.Backend.TimestampFields {
-				if !skipTimestampFilter && fieldTo == tsField {
-					skipTimestampFilter = true
-				}
-			}
+		if _, ok := operatorMap[req.Operator()]; !ok {
+			return fmt.Errorf("invalid selector operator %s", req.Operator())
 		}
 	}
 
-	if !skipTimestampFilter {
+	if !b.rest.hasLowerBound(b.rest.requirements(b.options)) {
 		q := b.query["query"].(map[string]interface{})["bool"].(map[string]interface{})["must"].([]map[string]interface{})
 		var should []map[string]interface{}
 
@@ -249,7 +388,12 @@ func (b *queryBuilder) namespaceFilter() error {
 		return nil
 	}
 
+	// Cluster wide requests of namespaced resources query all namespaces
 	ns, _ := request.NamespaceFrom(b.ctx)
+	if ns == "" {
+		return nil
+	}
+
 	nsFields := b.fieldMapping("metadata.namespace", []string{"metadata.namespace"})
 	q := b.query["query"].(map[string]interface{})["bool"].(map[string]interface{})["must"].([]map[string]interface{})
 	var should []map[string]interface{}
@@ -271,3 +415,92 @@ func (b *queryBuilder) namespaceFilter() error {
 	b.query["query"].(map[string]interface{})["bool"].(map[string]interface{})["must"] = q
 	return nil
 }
+
+// splitRequirements separates the requirements on the given keys from all other requirements
+func splitRequirements(requirements labels.Requirements, keys ...string) (matching, others labels.Requirements) {
+	for _, req := range requirements {
+		if containsKey(keys, req.Key()) {
+			matching = append(matching, req)
+		} else {
+			others = append(others, req)
+		}
+	}
+
+	return matching, others
+}
+
+// clusterFilter scopes the request to the selected kubernetes clusters.
+// Requests without a cluster selector are scoped to the default cluster while the cluster selector without a value selects all clusters.
+func (b *queryBuilder) clusterFilter(requirements labels.Requirements) queryBuilderFunc {
+	return func() error {
+		field := b.rest.opts.ClusterField
+		if field == "" {
+			for _, req := range requirements {
+				if req.Operator() != selection.Exists {
+					return apierrors.NewBadRequest(fmt.Sprintf("%s does not support the %s selector", b.rest.groupResource.String(), clusterSelector))
+				}
+			}
+
+			return nil
+		}
+
+		if len(requirements) == 0 {
+			if b.rest.opts.DefaultCluster == "" {
+				return nil
+			}
+
+			req, err := labels.NewRequirement(clusterSelector, selection.Equals, []string{b.rest.opts.DefaultCluster})
+			if err != nil {
+				return err
+			}
+
+			requirements = labels.Requirements{*req}
+		}
+
+		boolQuery := b.query["query"].(map[string]interface{})["bool"].(map[string]interface{})
+		for _, req := range requirements {
+			var should []map[string]interface{}
+			for _, cluster := range req.Values().List() {
+				should = append(should, map[string]interface{}{
+					"match_phrase": map[string]interface{}{
+						field: cluster,
+					},
+				})
+			}
+
+			condition := map[string]interface{}{
+				"bool": map[string]interface{}{
+					"should": should,
+				},
+			}
+
+			switch req.Operator() {
+			case selection.Exists:
+			case selection.DoesNotExist:
+				boolQuery["must_not"] = append(boolQuery["must_not"].([]map[string]interface{}), map[string]interface{}{
+					"exists": map[string]interface{}{
+						"field": field,
+					},
+				})
+			case selection.Equals, selection.DoubleEquals, selection.In:
+				boolQuery["must"] = append(boolQuery["must"].([]map[string]interface{}), condition)
+			case selection.NotEquals, selection.NotIn:
+				boolQuery["must_not"] = append(boolQuery["must_not"].([]map[string]interface{}), condition)
+			default:
+				return apierrors.NewBadRequest(fmt.Sprintf("invalid %s selector operator %s", clusterSelector, req.Operator()))
+			}
+		}
+
+		return nil
+	}
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
@@ -6,12 +6,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"time"
 
 	"github.com/Jeffail/gabs"
 	elasticsearch "github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"go.opentelemetry.io/otel/attribute"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -19,9 +22,12 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/cache"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/apiserver/pkg/registry/rest"
 	"k8s.io/klog/v2"
+
+	"github.com/raffis/kjournal/pkg/storage"
 )
 
 var _ rest.Scoper = &elasticsearchREST{}
@@ -38,7 +44,7 @@ func NewElasticsearchREST(
 	newFunc func() runtime.Object,
 	newListFunc func() runtime.Object,
 ) rest.Storage {
-	return &elasticsearchREST{
+	r := &elasticsearchREST{
 		groupResource: groupResource,
 		codec:         codec,
 		es:            es,
@@ -47,7 +53,18 @@ func NewElasticsearchREST(
 		isNamespaced:  isNamespaced,
 		newFunc:       newFunc,
 		newListFunc:   newListFunc,
+		cluster:       getCluster(es),
+	}
+
+	if opts.Backend.CacheSize > 0 {
+		r.cache = cache.NewLRUExpireCache(opts.Backend.CacheSize)
 	}
+
+	if opts.Backend.DataStream != "" {
+		r.dataStreams = newDataStreams(es)
+	}
+
+	return r
 }
 
 type elasticsearchREST struct {
@@ -60,6 +77,9 @@ type elasticsearchREST struct {
 	metaAccessor  meta.MetadataAccessor
 	newFunc       func() runtime.Object
 	newListFunc   func() runtime.Object
+	cache         *cache.LRUExpireCache
+	dataStreams   *dataStreams
+	cluster       *cluster
 }
 
 func (r *elasticsearchREST) New() runtime.Object {
@@ -91,26 +111,104 @@ type tableConvertor interface {
 
 // ConvertToTable implements the TableConvertor interface for REST.
 func (r *elasticsearchREST) ConvertToTable(ctx context.Context, obj runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	var (
+		tbl *metav1.Table
+		err error
+	)
+
 	if convert, ok := obj.(tableConvertor); ok {
+		tbl, err = convert.ConvertToTable(ctx, tableOptions)
+	} else {
+		tbl, err = rest.NewDefaultTableConvertor(r.groupResource).ConvertToTable(ctx, obj, tableOptions)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if meta.IsListType(obj) {
 		token, err := r.metaAccessor.Continue(obj)
 		if err != nil {
 			return nil, err
 		}
-		tbl, err := convert.ConvertToTable(ctx, tableOptions)
+
 		tbl.ListMeta.Continue = token
-		return tbl, err
 	}
 
-	return &metav1.Table{}, errors.New("could not convert to table")
+	if err := r.addColumns(tbl); err != nil {
+		return nil, err
+	}
+
+	return tbl, nil
+}
+
+// addColumns appends the configured columns to the table.
+// The cell values are looked up from the json representation of the row object.
+func (r *elasticsearchREST) addColumns(tbl *metav1.Table) error {
+	if len(r.opts.Columns) == 0 {
+		return nil
+	}
+
+	for _, column := range r.opts.Columns {
+		tbl.ColumnDefinitions = append(tbl.ColumnDefinitions, metav1.TableColumnDefinition{
+			Name:        column.Name,
+			Type:        "string",
+			Description: fmt.Sprintf("Value of %s", column.Path),
+		})
+	}
+
+	for i, row := range tbl.Rows {
+		var cells []interface{}
+		b, err := json.Marshal(row.Object.Object)
+		if err != nil {
+			return err
+		}
+
+		jsonParsed, err := gabs.ParseJSON(b)
+		if err != nil {
+			return err
+		}
+
+		for _, column := range r.opts.Columns {
+			cells = append(cells, columnValue(jsonParsed.Path(column.Path)))
+		}
+
+		tbl.Rows[i].Cells = append(tbl.Rows[i].Cells, cells...)
+	}
+
+	return nil
+}
+
+func columnValue(v *gabs.Container) interface{} {
+	if v == nil || v.Data() == nil {
+		return ""
+	}
+
+	switch data := v.Data().(type) {
+	case string, bool, float64:
+		return data
+	default:
+		return v.String()
+	}
 }
 
 func (r *elasticsearchREST) Watch(ctx context.Context, options *metainternalversion.ListOptions) (watch.Interface, error) {
 	klog.InfoS("Start watch stream", "options", options)
 
-	stream := &stream{
-		refreshRate: r.opts.Backend.RefreshRate,
-		rest:        r,
-		ch:          make(chan watch.Event, r.opts.Backend.BulkSize),
+	redactions := r.opts.Redactor.ForRequest(ctx)
+	if err := r.redactedSelectors(options, redactions); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := r.withTimeout(ctx, options)
+	stream := newStream(r, cancel)
+	stream.redactions = redactions
+	stream.usePIT = !r.crossCluster() && r.supportsPIT(ctx)
+
+	// New documents can not be appended to a descending stream or a stream sorted by another field than the timestamp,
+	// it ends once all existing documents are consumed
+	if descending(options) || sortedByField(options) {
+		stream.refreshRate = 0
 	}
 
 	go func() {
@@ -121,31 +219,54 @@ func (r *elasticsearchREST) Watch(ctx context.Context, options *metainternalvers
 	return stream, nil
 }
 
+// withTimeout derives a context from the request which is cancelled once the requested timeout is exceeded.
+// All backend queries of the request are bound to it.
+func (r *elasticsearchREST) withTimeout(ctx context.Context, options *metainternalversion.ListOptions) (context.Context, context.CancelFunc) {
+	if options != nil && options.TimeoutSeconds != nil && *options.TimeoutSeconds > 0 {
+		return context.WithTimeout(ctx, time.Duration(*options.TimeoutSeconds)*time.Second)
+	}
+
+	return context.WithCancel(ctx)
+}
+
 func (r *elasticsearchREST) List(
 	ctx context.Context,
 	options *metainternalversion.ListOptions,
 ) (runtime.Object, error) {
 	klog.InfoS("list request", "options", options)
 
+	ctx, cancel := r.withTimeout(ctx, options)
+	defer cancel()
+
 	newListObj := r.NewList()
 	v, err := getListPrt(newListObj)
 	if err != nil {
 		return nil, err
 	}
 
-	query, err := queryFromListOptions(ctx, options, r)
+	redactions := r.opts.Redactor.ForRequest(ctx)
+	query, err := queryFromListOptions(ctx, options, r, redactions)
 	if err != nil {
 		return newListObj, err
 	}
 
 	var hit esHit
 	esResults, err := r.fetch(ctx, query, options)
+	if errors.Is(err, context.DeadlineExceeded) {
+		// The deadline might also be inherited from the request context without a requested timeout
+		if options.TimeoutSeconds != nil {
+			return nil, apierrors.NewTimeoutError(fmt.Sprintf("list request did not complete within %ds", *options.TimeoutSeconds), 0)
+		}
+
+		return nil, apierrors.NewTimeoutError("list request did not complete within the request deadline", 0)
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
 	for _, hit = range esResults.Hits.Hits {
-		decodedObj, err := r.decodeFrom(hit)
+		decodedObj, err := r.decodeFrom(ctx, hit, redactions)
 		if err != nil {
 			return nil, err
 		}
@@ -175,8 +296,16 @@ func (r *elasticsearchREST) fetch(
 	ctx context.Context,
 	query map[string]interface{},
 	options *metainternalversion.ListOptions,
-) (esResults, error) {
-	var esResults esResults
+) (esResults esResults, err error) {
+	ctx, span := startSpan(ctx, "elasticsearch.fetch",
+		attribute.String("kjournal.resource", r.groupResource.Resource),
+		attribute.String("kjournal.continue", options.Continue),
+		attribute.Int64("kjournal.limit", options.Limit),
+	)
+
+	defer func() {
+		endSpan(span, err)
+	}()
 
 	// Build the request body.
 	var buf bytes.Buffer
@@ -185,15 +314,82 @@ func (r *elasticsearchREST) fetch(
 		return esResults, err
 	}
 
+	span.SetAttributes(r.queryAttribute(bytes.TrimSpace(buf.Bytes())))
+
+	var key string
+	var cacheable bool
+	if _, ok := query["pit"]; !ok {
+		key, cacheable = r.cacheKey(ctx, bytes.TrimSpace(buf.Bytes()), options)
+	}
+
+	if cacheable {
+		if cached, ok := r.cached(key); ok {
+			queryCacheHits.WithLabelValues(r.groupResource.Resource).Inc()
+			span.SetAttributes(attribute.Bool("kjournal.cache_hit", true))
+			return cached, nil
+		}
+
+		queryCacheMisses.WithLabelValues(r.groupResource.Resource).Inc()
+	}
+
+	var indices []string
+	if _, ok := query["pit"]; !ok {
+		indices = r.indices(ctx, options)
+	}
+
+	start := time.Now()
+	defer func() {
+		storage.QueryDuration.WithLabelValues(r.groupResource.Resource, backendName).Observe(time.Since(start).Seconds())
+	}()
+
+	if r.inspectIndices(ctx, indices) && r.supportsAsyncSearch(ctx) {
+		span.SetAttributes(attribute.Bool("elasticsearch.async", true))
+		esResults, err = r.asyncSearch(ctx, &buf, indices, options)
+	} else {
+		esResults, err = r.search(ctx, &buf, indices, options)
+	}
+
+	if err != nil {
+		storage.QueryErrors.WithLabelValues(r.groupResource.Resource, backendName).Inc()
+		return esResults, err
+	}
+
+	r.observeSearch(esResults)
+	if cacheable {
+		r.cache.Add(key, esResults, r.opts.Backend.CacheTTL)
+	}
+
+	span.SetAttributes(
+		attribute.Int("kjournal.hits", len(esResults.Hits.Hits)),
+		attribute.Int64("elasticsearch.took", esResults.Took),
+		attribute.Bool("elasticsearch.timed_out", esResults.TimedOut),
+		attribute.Int64("elasticsearch.shards.failed", esResults.Shards.Failed),
+	)
+
+	klog.InfoS("elasticsearch query result arrived", "duration", time.Duration(esResults.Took*int64(time.Millisecond)).String(), "timed-out", esResults.TimedOut, "number-of-hits", len(esResults.Hits.Hits), "shards", esResults.Shards)
+	return esResults, err
+}
+
+// search queries the indices using the search api
+func (r *elasticsearchREST) search(ctx context.Context, body io.Reader, indices []string, options *metainternalversion.ListOptions) (results esResults, err error) {
 	req := []func(*esapi.SearchRequest){
 		r.es.Search.WithContext(ctx),
-		r.es.Search.WithBody(&buf),
-		//r.es.Search.WithTimeout(time.Duration(int64(time.Second) * int64(*options.TimeoutSeconds))),
+		r.es.Search.WithBody(body),
 		r.es.Search.WithTrackTotalHits(false),
 	}
 
-	if _, ok := query["pit"]; !ok {
-		req = append(req, r.es.Search.WithIndex(r.opts.Backend.Index))
+	if r.opts.Backend.SearchTimeout != 0 {
+		req = append(req, r.es.Search.WithTimeout(r.opts.Backend.SearchTimeout))
+	}
+
+	// Searches using a point in time must not specify indices
+	if len(indices) > 0 {
+		req = append(req, r.es.Search.WithIndex(indices...))
+
+		// Namespaces or days without any documents might not have an index yet
+		if r.ignoreUnavailable() {
+			req = append(req, r.es.Search.WithIgnoreUnavailable(true))
+		}
 	}
 
 	if options.Limit != 0 {
@@ -203,31 +399,47 @@ func (r *elasticsearchREST) fetch(
 	res, err := r.es.Search(req...)
 	if err != nil {
 		klog.ErrorS(err, "error getting response from es")
-		return esResults, err
+		return results, err
 	}
 
 	defer res.Body.Close()
+	err = decodeResponse(res, &results)
+	return results, err
+}
 
+// decodeResponse decodes the body of a successful response
+func decodeResponse(res *esapi.Response, v interface{}) error {
 	if res.IsError() {
 		var e map[string]interface{}
 		if err := json.NewDecoder(res.Body).Decode(&e); err != nil {
 			klog.ErrorS(err, "error parsing the response body")
-			return esResults, err
-		} else {
-			klog.ErrorS(err, "error parsing the response body", "status", res.Status(), "body", e)
-			return esResults, err
+			return err
 		}
+
+		err := fmt.Errorf("elasticsearch search failed with status %s", res.Status())
+		klog.ErrorS(err, "elasticsearch search failed", "body", e)
+		return err
 	}
 
-	if err := json.NewDecoder(res.Body).Decode(&esResults); err != nil {
-		return esResults, err
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func (r *elasticsearchREST) decodeFrom(ctx context.Context, obj esHit, redactions *storage.Redactions) (runtime.Object, error) {
+	ctx, span := startSpan(ctx, "elasticsearch.decodeFrom",
+		attribute.String("elasticsearch.index", obj.Index),
+		attribute.String("elasticsearch.id", obj.ID),
+	)
+
+	decodedObj, err := r.decode(obj, redactions)
+	if err != nil {
+		storage.DecodeErrors.WithLabelValues(r.groupResource.Resource, backendName).Inc()
 	}
 
-	klog.InfoS("elasticsearch query result arrived", "duration", time.Duration(esResults.Took*int64(time.Millisecond)).String(), "timed-out", esResults.TimedOut, "number-of-hits", len(esResults.Hits.Hits), "shards", esResults.Shards)
-	return esResults, err
+	endSpan(span, err)
+	return decodedObj, err
 }
 
-func (r *elasticsearchREST) decodeFrom(obj esHit) (runtime.Object, error) {
+func (r *elasticsearchREST) decode(obj esHit, redactions *storage.Redactions) (runtime.Object, error) {
 	newObj := r.newFunc()
 
 	jsonParsed, err := gabs.ParseJSON(obj.Source)
@@ -261,6 +473,10 @@ func (r *elasticsearchREST) decodeFrom(obj esHit) (runtime.Object, error) {
 		_ = jsonParsed.DeleteP(field)
 	}
 
+	if err := redactions.Redact(jsonParsed); err != nil {
+		return newObj, err
+	}
+
 	decodedObj, _, err := r.codec.Decode(jsonParsed.Bytes(), nil, newObj)
 	if err != nil {
 		return nil, err
@@ -272,6 +488,10 @@ func (r *elasticsearchREST) decodeFrom(obj esHit) (runtime.Object, error) {
 	}
 
 	annotations["kjournal/es-index"] = obj.Index
+	if cluster := r.clusterName(obj.Index); cluster != "" {
+		annotations[annotationESCluster] = cluster
+	}
+
 	if err := r.metaAccessor.SetAnnotations(decodedObj, annotations); err != nil {
 		return decodedObj, err
 	}
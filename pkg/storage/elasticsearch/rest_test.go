@@ -8,21 +8,34 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	elasticsearch "github.com/elastic/go-elasticsearch/v8"
 	"gotest.tools/v3/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/registry/rest"
 	srvstorage "k8s.io/apiserver/pkg/server/storage"
 	"k8s.io/apiserver/pkg/storage/storagebackend"
+
+	configv1alpha1 "github.com/raffis/kjournal/pkg/apis/config/v1alpha1"
+	"github.com/raffis/kjournal/pkg/storage"
 )
 
 // Mock transport replaces the HTTP transport for tests
@@ -76,7 +89,7 @@ func TestList(t *testing.T) {
 					},
 				},
 			},
-			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":null}},{"bool":{"should":[{"match_phrase":{"metadata.namespace":""}}]}}],"must_not":[]}},"sort":[]}
+			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":null}}],"must_not":[]}},"sort":[]}
 `,
 			expectedResult: &DummyList{
 				Items: []Dummy{
@@ -108,7 +121,7 @@ func TestList(t *testing.T) {
 					},
 				},
 			},
-			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":null}},{"bool":{"should":[{"match_phrase":{"metadata.namespace":""}}]}}],"must_not":[]}},"sort":[]}
+			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":null}}],"must_not":[]}},"sort":[]}
 `,
 			expectedResult: &DummyList{
 				ListMeta: v1.ListMeta{
@@ -143,7 +156,7 @@ func TestList(t *testing.T) {
 					},
 				},
 			},
-			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":null}},{"bool":{"should":[{"match_phrase":{"metadata.namespace":""}}]}}],"must_not":[]}},"search_after":["sortFieldA"],"sort":[]}
+			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":null}}],"must_not":[]}},"search_after":["sortFieldA"],"sort":[]}
 `,
 			expectedResult: &DummyList{
 				Items: []Dummy{
@@ -187,7 +200,7 @@ func TestList(t *testing.T) {
 					FieldSelector: fields.Everything(),
 				}
 			},
-			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":[{"range":{"fieldA":{"lt":"1"}}}]}},{"bool":{"should":[{"range":{"fieldA":{"gt":"1"}}}]}},{"bool":{"should":[{"match_phrase":{"fieldA":"fieldB"}}]}},{"bool":{"should":[{"match_phrase":{"fieldA":"fieldB"}}]}},{"bool":{"should":[null]}},{"bool":{"should":null}},{"bool":{"should":[{"match_phrase":{"metadata.namespace":""}}]}}],"must_not":[{"bool":{"should":[{"match_phrase":{"fieldA":"fieldB"}}]}},{"bool":{"should":[{"exists":{"field":"fieldA"}}]}}]}},"sort":[]}
+			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":[{"range":{"fieldA":{"lt":"1"}}}]}},{"bool":{"should":[{"range":{"fieldA":{"gt":"1"}}}]}},{"bool":{"should":[{"match_phrase":{"fieldA":"fieldB"}}]}},{"bool":{"should":[{"match_phrase":{"fieldA":"fieldB"}}]}},{"bool":{"should":[null]}},{"bool":{"should":null}}],"must_not":[{"bool":{"should":[{"match_phrase":{"fieldA":"fieldB"}}]}},{"bool":{"should":[{"exists":{"field":"fieldA"}}]}}]}},"sort":[]}
 `,
 		},
 		{
@@ -205,7 +218,20 @@ func TestList(t *testing.T) {
 					FieldSelector: fields.Everything(),
 				}
 			},
-			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":[{"range":{"toFieldA":{"lt":"1"}}}]}},{"bool":{"should":[{"range":{"toFieldA":{"gt":"1"}}}]}},{"bool":{"should":[{"match_phrase":{"toFieldA":"fieldB"}}]}},{"bool":{"should":[{"match_phrase":{"toFieldA":"fieldB"}}]}},{"bool":{"should":[null]}},{"bool":{"should":null}},{"bool":{"should":[{"match_phrase":{"metadata.namespace":""}}]}}],"must_not":[{"bool":{"should":[{"match_phrase":{"toFieldA":"fieldB"}}]}},{"bool":{"should":[{"exists":{"field":"toFieldA"}}]}}]}},"sort":[]}
+			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":[{"range":{"toFieldA":{"lt":"1"}}}]}},{"bool":{"should":[{"range":{"toFieldA":{"gt":"1"}}}]}},{"bool":{"should":[{"match_phrase":{"toFieldA":"fieldB"}}]}},{"bool":{"should":[{"match_phrase":{"toFieldA":"fieldB"}}]}},{"bool":{"should":[null]}},{"bool":{"should":null}}],"must_not":[{"bool":{"should":[{"match_phrase":{"toFieldA":"fieldB"}}]}},{"bool":{"should":[{"exists":{"field":"toFieldA"}}]}}]}},"sort":[]}
+`,
+		},
+		{
+			name: "Set based selectors get mapped to a correct elasticsearch query",
+			listOpts: func() *metainternalversion.ListOptions {
+				selectors, _ := labels.Parse("metadata.namespace in (a,b),fieldA notin (c)")
+
+				return &metainternalversion.ListOptions{
+					LabelSelector: selectors,
+					FieldSelector: fields.Everything(),
+				}
+			},
+			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":[{"match_phrase":{"metadata.namespace":"a"}},{"match_phrase":{"metadata.namespace":"b"}}]}},{"bool":{"should":null}}],"must_not":[{"bool":{"should":[{"match_phrase":{"fieldA":"c"}}]}}]}},"sort":[]}
 `,
 		},
 		{
@@ -227,28 +253,210 @@ func TestList(t *testing.T) {
 					FieldSelector: fields.Everything(),
 				}
 			},
-			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":[{"range":{"timestampField":{"gte":"now-24h"}}}]}},{"bool":{"should":[{"match_phrase":{"metadata.namespace":""}}]}}],"must_not":[]}},"sort":[{"timestampField":{"order":"asc","unmapped_type":"long"}}]}
+			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":[{"range":{"timestampField":{"gte":"now-24h"}}}]}}],"must_not":[]}},"sort":[{"timestampField":{"order":"asc","unmapped_type":"long"}}]}
+`,
+		},
+		{
+			name: "Default time range gets ignored if a filter sets a lower bound on the defined timestamp field",
+			opts: Options{
+				DefaultTimeRange: "now-24h",
+				Backend: OptionsBackend{
+					TimestampFields: []string{"timestampField"},
+				},
+			},
+			listOpts: func() *metainternalversion.ListOptions {
+				selectors, _ := labels.Parse("timestampField>1")
+
+				return &metainternalversion.ListOptions{
+					LabelSelector: selectors,
+					FieldSelector: fields.Everything(),
+				}
+			},
+			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":[{"range":{"timestampField":{"gt":"1"}}}]}}],"must_not":[]}},"sort":[{"timestampField":{"order":"asc","unmapped_type":"long"}}]}
+`,
+		},
+		{
+			name: "Default time range is kept if a filter sets an upper bound only on the defined timestamp field",
+			opts: Options{
+				DefaultTimeRange: "now-24h",
+				Backend: OptionsBackend{
+					TimestampFields: []string{"timestampField"},
+				},
+			},
+			listOpts: func() *metainternalversion.ListOptions {
+				selectors, _ := labels.Parse("timestampField<1666263600000")
+
+				return &metainternalversion.ListOptions{
+					LabelSelector: selectors,
+					FieldSelector: fields.Everything(),
+				}
+			},
+			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":[{"range":{"timestampField":{"lt":"1666263600000"}}}]}},{"bool":{"should":[{"range":{"timestampField":{"gte":"now-24h"}}}]}}],"must_not":[]}},"sort":[{"timestampField":{"order":"asc","unmapped_type":"long"}}]}
+`,
+		},
+		{
+			name: "Descending sort order with a continue token",
+			opts: Options{
+				FieldMap: map[string][]string{
+					"metadata.uid": []string{"_id"},
+				},
+				DefaultTimeRange: "now-24h",
+				Backend: OptionsBackend{
+					TimestampFields: []string{"timestampField"},
+				},
+			},
+			listOpts: func() *metainternalversion.ListOptions {
+				selectors, _ := labels.Parse("sortOrder=desc")
+
+				return &metainternalversion.ListOptions{
+					LabelSelector: selectors,
+					FieldSelector: fields.Everything(),
+					Continue:      `[1665991259000,"a"]`,
+				}
+			},
+			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":[{"range":{"timestampField":{"gte":"now-24h"}}}]}}],"must_not":[]}},"search_after":[1665991259000,"a"],"sort":[{"timestampField":{"order":"desc","unmapped_type":"long"}},{"_id":{"order":"desc","unmapped_type":"long"}}]}
+`,
+		},
+		{
+			name: "Sort by a mapped field",
+			opts: Options{
+				FieldMap: map[string][]string{
+					"payload.level": []string{"level"},
+				},
+				DefaultTimeRange: "now-24h",
+				Backend: OptionsBackend{
+					TimestampFields: []string{"timestampField"},
+				},
+			},
+			listOpts: func() *metainternalversion.ListOptions {
+				selectors, _ := labels.Parse("sortBy=payload.level")
+
+				return &metainternalversion.ListOptions{
+					LabelSelector: selectors,
+					FieldSelector: fields.Everything(),
+				}
+			},
+			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":[{"range":{"timestampField":{"gte":"now-24h"}}}]}}],"must_not":[]}},"sort":[{"level":{"order":"asc","unmapped_type":"long"}},{"timestampField":{"order":"asc","unmapped_type":"long"}}]}
+`,
+		},
+		{
+			name: "Sort by a mapped field is followed by the timestamp and uid fields",
+			opts: Options{
+				FieldMap: map[string][]string{
+					"payload.level": []string{"level"},
+					"metadata.uid":  []string{"_id"},
+				},
+				DefaultTimeRange: "now-24h",
+				Backend: OptionsBackend{
+					TimestampFields: []string{"timestampField"},
+				},
+			},
+			listOpts: func() *metainternalversion.ListOptions {
+				selectors, _ := labels.Parse("sortBy=payload.level,sortOrder=desc")
+
+				return &metainternalversion.ListOptions{
+					LabelSelector: selectors,
+					FieldSelector: fields.Everything(),
+				}
+			},
+			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":[{"range":{"timestampField":{"gte":"now-24h"}}}]}}],"must_not":[]}},"sort":[{"level":{"order":"desc","unmapped_type":"long"}},{"timestampField":{"order":"desc","unmapped_type":"long"}},{"_id":{"order":"desc","unmapped_type":"long"}}]}
 `,
 		},
 		{
-			name: "Default time range gets ignored if a filter matches the defined timestamp field",
+			name: "Sort by the creation timestamp does not repeat the timestamp fields",
 			opts: Options{
+				FieldMap: map[string][]string{
+					"metadata.uid": []string{"_id"},
+				},
 				DefaultTimeRange: "now-24h",
 				Backend: OptionsBackend{
 					TimestampFields: []string{"timestampField"},
 				},
 			},
 			listOpts: func() *metainternalversion.ListOptions {
-				selectors, _ := labels.Parse("timestampField<1")
+				selectors, _ := labels.Parse("sortBy=metadata.creationTimestamp")
+
+				return &metainternalversion.ListOptions{
+					LabelSelector: selectors,
+					FieldSelector: fields.Everything(),
+				}
+			},
+			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":[{"range":{"timestampField":{"gte":"now-24h"}}}]}}],"must_not":[]}},"sort":[{"timestampField":{"order":"asc","unmapped_type":"long"}},{"_id":{"order":"asc","unmapped_type":"long"}}]}
+`,
+		},
+		{
+			name: "Invalid sort order is rejected",
+			listOpts: func() *metainternalversion.ListOptions {
+				selectors, _ := labels.Parse("sortOrder=newest")
+
+				return &metainternalversion.ListOptions{
+					LabelSelector: selectors,
+					FieldSelector: fields.Everything(),
+				}
+			},
+			expectedError: errors.New("invalid sort order newest, either asc or desc is supported"),
+		},
+		{
+			name: "Query is scoped to the default cluster without a cluster selector",
+			opts: Options{
+				ClusterField:   "kubernetes.cluster",
+				DefaultCluster: "prod-a",
+			},
+			listOpts: func() *metainternalversion.ListOptions {
+				return &metainternalversion.ListOptions{
+					LabelSelector: labels.Everything(),
+					FieldSelector: fields.Everything(),
+				}
+			},
+			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":null}},{"bool":{"should":[{"match_phrase":{"kubernetes.cluster":"prod-a"}}]}}],"must_not":[]}},"sort":[]}
+`,
+		},
+		{
+			name: "Cluster selector gets mapped to the cluster field",
+			opts: Options{
+				ClusterField:   "kubernetes.cluster",
+				DefaultCluster: "prod-a",
+			},
+			listOpts: func() *metainternalversion.ListOptions {
+				selectors, _ := labels.Parse("cluster in (prod-b,prod-c)")
+
+				return &metainternalversion.ListOptions{
+					LabelSelector: selectors,
+					FieldSelector: fields.Everything(),
+				}
+			},
+			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":null}},{"bool":{"should":[{"match_phrase":{"kubernetes.cluster":"prod-b"}},{"match_phrase":{"kubernetes.cluster":"prod-c"}}]}}],"must_not":[]}},"sort":[]}
+`,
+		},
+		{
+			name: "Cluster selector without a value queries all clusters",
+			opts: Options{
+				ClusterField:   "kubernetes.cluster",
+				DefaultCluster: "prod-a",
+			},
+			listOpts: func() *metainternalversion.ListOptions {
+				selectors, _ := labels.Parse("cluster")
 
 				return &metainternalversion.ListOptions{
 					LabelSelector: selectors,
 					FieldSelector: fields.Everything(),
 				}
 			},
-			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":[{"range":{"timestampField":{"lt":"1"}}}]}},{"bool":{"should":[{"match_phrase":{"metadata.namespace":""}}]}}],"must_not":[]}},"sort":[{"timestampField":{"order":"asc","unmapped_type":"long"}}]}
+			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":null}}],"must_not":[]}},"sort":[]}
 `,
 		},
+		{
+			name: "Cluster selector is rejected if no cluster field is configured",
+			listOpts: func() *metainternalversion.ListOptions {
+				selectors, _ := labels.Parse("cluster=prod-b")
+
+				return &metainternalversion.ListOptions{
+					LabelSelector: selectors,
+					FieldSelector: fields.Everything(),
+				}
+			},
+			expectedError: errors.New("Dummy.testing does not support the cluster selector"),
+		},
 		{
 			name: "Response gets mapped with a field map",
 			opts: Options{
@@ -277,7 +485,7 @@ func TestList(t *testing.T) {
 					},
 				},
 			},
-			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":[{"range":{"timestampField":{"gte":"now-24h"}}}]}},{"bool":{"should":[{"match_phrase":{"metadata.namespace":""}}]}}],"must_not":[]}},"sort":[{"timestampField":{"order":"asc","unmapped_type":"long"}}]}
+			expectedESRequest: `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":[{"range":{"timestampField":{"gte":"now-24h"}}}]}}],"must_not":[]}},"sort":[{"timestampField":{"order":"asc","unmapped_type":"long"}}]}
 `,
 			expectedResult: &DummyList{
 				Items: []Dummy{
@@ -428,3 +636,1459 @@ func TestList(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertToTable(t *testing.T) {
+	dummy := &Dummy{}
+	restStorage := NewElasticsearchREST(
+		dummy.GetGroupVersionResource().GroupResource(),
+		nil,
+		nil,
+		Options{
+			Columns: []configv1alpha1.Column{
+				{Name: "LEVEL", Path: "payload.level"},
+				{Name: "MISSING", Path: "payload.missing"},
+			},
+		},
+		dummy.NamespaceScoped(),
+		dummy.New,
+		dummy.NewList,
+	)
+
+	list := &DummyList{
+		ListMeta: v1.ListMeta{
+			Continue: `["sortFieldA"]`,
+		},
+		Items: []Dummy{
+			{
+				ObjectMeta: v1.ObjectMeta{
+					Name: "a",
+				},
+				Payload: json.RawMessage(`{"level":"error"}`),
+			},
+		},
+	}
+
+	tbl, err := restStorage.(rest.TableConvertor).ConvertToTable(context.TODO(), list, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, `["sortFieldA"]`, tbl.Continue)
+	assert.Equal(t, 4, len(tbl.ColumnDefinitions))
+	assert.Equal(t, "LEVEL", tbl.ColumnDefinitions[2].Name)
+	assert.Equal(t, 1, len(tbl.Rows))
+	assert.Equal(t, "a", tbl.Rows[0].Cells[0])
+	assert.Equal(t, "error", tbl.Rows[0].Cells[2])
+	assert.Equal(t, "", tbl.Rows[0].Cells[3])
+}
+
+func TestTimeRange(t *testing.T) {
+	now := time.Date(2022, 10, 20, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		selector      string
+		defaultRange  string
+		from          time.Time
+		to            time.Time
+		expectedError string
+	}{
+		{
+			name: "Default time range is used without timestamp selectors",
+			from: now.Add(-24 * time.Hour),
+			to:   now,
+		},
+		{
+			name:     "Lower bound from epoch millis",
+			selector: "metadata.creationTimestamp>1666184400000",
+			from:     time.UnixMilli(1666184400000),
+			to:       now,
+		},
+		{
+			name:         "Default time range with date math",
+			defaultRange: "now-7d/d",
+			selector:     "metadata.creationTimestamp<1666263600000",
+			from:         now.Add(-7 * 24 * time.Hour).Truncate(24 * time.Hour),
+			to:           time.UnixMilli(1666263600000),
+		},
+		{
+			name:         "Default time range rounded to the start of the previous month",
+			defaultRange: "now-1M/M",
+			from:         time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC),
+			to:           now,
+		},
+		{
+			name:     "Non timestamp fields are ignored",
+			selector: "payload.level>1666184400000",
+			from:     now.Add(-24 * time.Hour),
+			to:       now,
+		},
+		{
+			name:          "Unsupported time format",
+			selector:      "metadata.creationTimestamp=yesterday",
+			expectedError: "unsupported time format yesterday",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := MakeDefaultOptions()
+			opts.FieldMap = map[string][]string{
+				"metadata.creationTimestamp": {"@timestamp"},
+			}
+
+			if test.defaultRange != "" {
+				opts.DefaultTimeRange = test.defaultRange
+			}
+
+			restStorage := NewElasticsearchREST((&Dummy{}).GetGroupVersionResource().GroupResource(), nil, nil, opts, true, nil, nil)
+
+			requirements, err := labels.ParseToRequirements(test.selector)
+			assert.NilError(t, err)
+
+			from, to, err := restStorage.(*elasticsearchREST).timeRange(requirements, now)
+			if test.expectedError != "" {
+				assert.Error(t, err, test.expectedError)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Equal(t, test.from, from)
+			assert.Equal(t, test.to, to)
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2022, 3, 31, 12, 30, 15, 0, time.UTC)
+	zurich, err := time.LoadLocation("Europe/Zurich")
+	assert.NilError(t, err)
+
+	tests := []struct {
+		name          string
+		value         string
+		now           time.Time
+		expected      time.Time
+		expectedError string
+	}{
+		{
+			name:     "Epoch millis",
+			value:    "1666184400000",
+			now:      now,
+			expected: time.UnixMilli(1666184400000),
+		},
+		{
+			name:     "Date",
+			value:    "2022-10-19",
+			now:      now,
+			expected: time.Date(2022, 10, 19, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Subtracting a month clamps the day to the end of the month",
+			value:    "now-1M",
+			now:      now,
+			expected: time.Date(2022, 2, 28, 12, 30, 15, 0, time.UTC),
+		},
+		{
+			name:     "Subtracting a year respects leap years",
+			value:    "now-1y",
+			now:      time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2023, 2, 28, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Adding days",
+			value:    "now+2d",
+			now:      now,
+			expected: time.Date(2022, 4, 2, 12, 30, 15, 0, time.UTC),
+		},
+		{
+			name:     "Rounding to the month",
+			value:    "now-1d/M",
+			now:      now,
+			expected: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Rounding to the year",
+			value:    "now/y",
+			now:      now,
+			expected: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Rounding to the week starts on monday",
+			value:    "now/w",
+			now:      now,
+			expected: time.Date(2022, 3, 28, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Rounding to the hour",
+			value:    "now-1h/h",
+			now:      now,
+			expected: time.Date(2022, 3, 31, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Date math is evaluated in UTC like elasticsearch",
+			value:    "now/d",
+			now:      time.Date(2022, 4, 1, 0, 30, 0, 0, zurich),
+			expected: time.Date(2022, 3, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "Unsupported date math unit",
+			value:         "now-1q",
+			now:           now,
+			expectedError: "unsupported date math unit q",
+		},
+		{
+			name:          "Unsupported rounding unit",
+			value:         "now/q",
+			now:           now,
+			expectedError: "unsupported date math unit q",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ts, err := parseTime(test.value, test.now)
+			if test.expectedError != "" {
+				assert.Error(t, err, test.expectedError)
+				return
+			}
+
+			assert.NilError(t, err)
+			assert.Assert(t, test.expected.Equal(ts), "expected %s, got %s", test.expected, ts)
+		})
+	}
+}
+
+func TestIndices(t *testing.T) {
+	tests := []struct {
+		name           string
+		namespace      string
+		namespaced     bool
+		index          string
+		namespaceIndex map[string]string
+		clusters       []string
+		expected       []string
+	}{
+		{
+			name:       "Cluster scoped resources use the index as is",
+			namespace:  "team-a",
+			namespaced: false,
+			index:      "logs-{namespace}.*",
+			expected:   []string{"logs-{namespace}.*"},
+		},
+		{
+			name:       "Namespace placeholder is replaced",
+			namespace:  "team-a",
+			namespaced: true,
+			index:      "logs-{namespace}.*",
+			expected:   []string{"logs-team-a.*"},
+		},
+		{
+			name:           "Namespace is routed to the mapped index",
+			namespace:      "team-a",
+			namespaced:     true,
+			index:          "logs-*",
+			namespaceIndex: map[string]string{"team-a": "tenant-a-*"},
+			expected:       []string{"tenant-a-*"},
+		},
+		{
+			name:           "All namespaces query all indices",
+			namespaced:     true,
+			index:          "logs-{namespace}.*",
+			namespaceIndex: map[string]string{"team-b": "tenant-b-*", "team-a": "tenant-a-*"},
+			expected:       []string{"logs-*.*", "tenant-a-*", "tenant-b-*"},
+		},
+		{
+			name:       "Index patterns are searched on all clusters",
+			namespace:  "team-a",
+			namespaced: true,
+			index:      "logs-{namespace}.*,events-*",
+			clusters:   []string{"local", "eu", "us"},
+			expected:   []string{"logs-team-a.*,events-*,eu:logs-team-a.*,eu:events-*,us:logs-team-a.*,us:events-*"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := MakeDefaultOptions()
+			opts.Backend.Index = test.index
+			opts.Backend.NamespaceIndex = test.namespaceIndex
+			opts.Backend.Clusters = test.clusters
+
+			restStorage := NewElasticsearchREST((&Dummy{}).GetGroupVersionResource().GroupResource(), nil, nil, opts, test.namespaced, nil, nil)
+			ctx := request.WithNamespace(context.TODO(), test.namespace)
+			assert.DeepEqual(t, test.expected, restStorage.(*elasticsearchREST).indices(ctx, &metainternalversion.ListOptions{}))
+		})
+	}
+}
+
+func TestNamespaceIndexValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		index         string
+		namespace     string
+		indices       []string
+		expected      []string
+		expectedError bool
+	}{
+		{
+			name:          "Wildcard delimited by a dash is rejected",
+			index:         "logs-{namespace}-*",
+			expectedError: true,
+		},
+		{
+			name:          "Wildcard directly after the placeholder is rejected",
+			index:         "logs-{namespace}*",
+			expectedError: true,
+		},
+		{
+			name:          "Date template delimited by a dash is rejected",
+			index:         "events-*,logs-{namespace}-{yyyy.MM.dd}",
+			expectedError: true,
+		},
+		{
+			name:  "Placeholder without a wildcard suffix is accepted",
+			index: "logs-{namespace}-app",
+		},
+		{
+			name:      "Namespace prefix does not match the indices of other namespaces",
+			index:     "logs-{namespace}.*",
+			namespace: "team",
+			indices:   []string{"logs-team.2022.10.19", "logs-team-b.2022.10.19", "logs-teams.2022.10.19"},
+			expected:  []string{"logs-team.2022.10.19"},
+		},
+		{
+			name:      "Date template delimited by an underscore is accepted",
+			index:     "logs-{namespace}_{yyyy.MM.dd}",
+			namespace: "team",
+			indices:   []string{"logs-team_2022.10.19", "logs-team-b_2022.10.19"},
+			expected:  []string{"logs-team_2022.10.19"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			apiBinding := &configv1alpha1.API{}
+			apiBinding.Backend.Elasticsearch.Index = test.index
+
+			opts, err := MakeOptionsFromConfig(apiBinding)
+			if test.expectedError {
+				assert.ErrorContains(t, err, "ambiguous index pattern")
+				return
+			}
+
+			assert.NilError(t, err)
+
+			restStorage := NewElasticsearchREST((&Dummy{}).GetGroupVersionResource().GroupResource(), nil, nil, opts, true, nil, nil)
+			ctx := request.WithNamespace(context.TODO(), test.namespace)
+			patterns := restStorage.(*elasticsearchREST).indexPatterns(ctx)
+			assert.Equal(t, len(patterns), 1)
+
+			var matched []string
+			for _, index := range test.indices {
+				ok, err := path.Match(wildcardDateTemplate(patterns[0]), index)
+				assert.NilError(t, err)
+				if ok {
+					matched = append(matched, index)
+				}
+			}
+
+			assert.DeepEqual(t, test.expected, matched)
+		})
+	}
+}
+
+func TestDateIndices(t *testing.T) {
+	from := time.Date(2022, 10, 19, 22, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		pattern  string
+		from     time.Time
+		to       time.Time
+		expected []string
+	}{
+		{
+			name:     "Patterns without a date template are used as is",
+			pattern:  "container-*",
+			from:     from,
+			to:       from.Add(time.Hour),
+			expected: []string{"container-*"},
+		},
+		{
+			name:     "Daily indices overlapping the time range are resolved",
+			pattern:  "container-{yyyy.MM.dd}",
+			from:     from,
+			to:       from.Add(2 * time.Hour),
+			expected: []string{"container-2022.10.19", "container-2022.10.20"},
+		},
+		{
+			name:     "Hourly indices overlapping the time range are resolved",
+			pattern:  "container-{yyyy.MM.dd.HH}",
+			from:     from,
+			to:       from.Add(time.Hour),
+			expected: []string{"container-2022.10.19.22", "container-2022.10.19.23"},
+		},
+		{
+			name:     "Monthly indices overlapping the time range are resolved",
+			pattern:  "{namespace}-{yyyy-MM}",
+			from:     from,
+			to:       from.AddDate(0, 1, 0),
+			expected: []string{"{namespace}-2022-10", "{namespace}-2022-11"},
+		},
+		{
+			name:     "Time range without a lower bound falls back to a wildcard",
+			pattern:  "container-{yyyy.MM.dd}",
+			to:       from,
+			expected: []string{"container-*"},
+		},
+		{
+			name:     "Time range exceeding the maximum number of indices falls back to a wildcard",
+			pattern:  "container-{yyyy.MM.dd}",
+			from:     from,
+			to:       from.AddDate(1, 0, 0),
+			expected: []string{"container-*"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.DeepEqual(t, test.expected, dateIndices(test.pattern, test.from, test.to))
+		})
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	hashKeyFile := filepath.Join(t.TempDir(), "key")
+	assert.NilError(t, os.WriteFile(hashKeyFile, []byte("secret-key\n"), 0600))
+
+	tests := []struct {
+		name          string
+		groups        []string
+		redactions    []configv1alpha1.Redaction
+		expected      string
+		expectedName  string
+		expectedError string
+	}{
+		{
+			name: "Fields are masked",
+			redactions: []configv1alpha1.Redaction{
+				{Fields: []string{"payload.token"}},
+			},
+			expected: `{"email":"jane@example.com","token":"****"}`,
+		},
+		{
+			name: "Fields are dropped",
+			redactions: []configv1alpha1.Redaction{
+				{Fields: []string{"payload.token"}, Action: configv1alpha1.RedactionActionDrop},
+			},
+			expected: `{"email":"jane@example.com"}`,
+		},
+		{
+			name: "Pattern matches are hashed with the hash key",
+			redactions: []configv1alpha1.Redaction{
+				{Patterns: []string{`[a-z]+@example\.com`}, Action: configv1alpha1.RedactionActionHash, HashKeyFile: hashKeyFile},
+			},
+			expected:     `{"email":"hmac-sha256:b110db8f3c4f5c8d470c3fe53308ca38128a5127e5e0773411f9cfd7e7305380","token":"secret"}`,
+			expectedName: "jane@example.com",
+		},
+		{
+			name: "Hash action requires a hash key",
+			redactions: []configv1alpha1.Redaction{
+				{Fields: []string{"payload.token"}, Action: configv1alpha1.RedactionActionHash},
+			},
+			expectedError: "invalid redaction: redaction 0 requires a hashKeyFile for the hash action",
+		},
+		{
+			name:   "Exempt groups are not redacted",
+			groups: []string{"auditors"},
+			redactions: []configv1alpha1.Redaction{
+				{Fields: []string{"payload.token"}, ExemptGroups: []string{"auditors"}},
+			},
+			expected: `{"email":"jane@example.com","token":"secret"}`,
+		},
+		{
+			name: "Redaction is restricted to groups",
+			redactions: []configv1alpha1.Redaction{
+				{Fields: []string{"payload.token"}, Groups: []string{"developers"}},
+			},
+			expected: `{"email":"jane@example.com","token":"secret"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dummy := &Dummy{}
+			codec, _, _ := srvstorage.NewStorageCodec(srvstorage.StorageCodecConfig{
+				StorageMediaType:  runtime.ContentTypeJSON,
+				StorageSerializer: serializer.NewCodecFactory(&runtime.Scheme{}),
+				Config:            storagebackend.Config{},
+			})
+
+			redactor, err := storage.NewRedactor(dummy.GetGroupVersionResource().GroupResource(), test.redactions, nil)
+			if test.expectedError != "" {
+				assert.Error(t, err, test.expectedError)
+				return
+			}
+
+			assert.NilError(t, err)
+
+			opts := MakeDefaultOptions()
+			opts.Redactor = redactor
+			restStorage := NewElasticsearchREST(dummy.GetGroupVersionResource().GroupResource(), codec, nil, opts, true, dummy.New, dummy.NewList)
+
+			ctx := request.WithUser(context.TODO(), &user.DefaultInfo{Name: "jane", Groups: test.groups})
+			obj, err := restStorage.(*elasticsearchREST).decodeFrom(ctx, esHit{
+				ID:     "a",
+				Source: json.RawMessage(`{"metadata":{"name":"jane@example.com"},"payload":{"email":"jane@example.com","token":"secret"}}`),
+			}, redactor.ForRequest(ctx))
+
+			assert.NilError(t, err)
+			assert.Equal(t, test.expected, string(obj.(*Dummy).Payload))
+			if test.expectedName != "" {
+				assert.Equal(t, test.expectedName, obj.(*Dummy).Name)
+			}
+		})
+	}
+}
+
+func TestRedactedSelectors(t *testing.T) {
+	tests := []struct {
+		name               string
+		selector           string
+		groups             []string
+		allowed            bool
+		fieldMap           map[string][]string
+		redactions         []configv1alpha1.Redaction
+		expectedForbidden  bool
+		expectedAuthorized int
+	}{
+		{
+			name:     "Selectors on unredacted fields are accepted",
+			selector: "payload.email=jane",
+			redactions: []configv1alpha1.Redaction{
+				{Fields: []string{"payload.token"}},
+			},
+			expectedAuthorized: 1,
+		},
+		{
+			name:     "Selectors on redacted fields are rejected",
+			selector: "payload.token=secret",
+			redactions: []configv1alpha1.Redaction{
+				{Fields: []string{"payload.token"}},
+			},
+			expectedForbidden:  true,
+			expectedAuthorized: 1,
+		},
+		{
+			name:     "Selectors on the parent of a redacted field are rejected",
+			selector: "payload",
+			redactions: []configv1alpha1.Redaction{
+				{Fields: []string{"payload.token"}},
+			},
+			expectedForbidden:  true,
+			expectedAuthorized: 1,
+		},
+		{
+			name:     "Selectors on the backend path of a redacted field are rejected",
+			selector: "log.token=secret",
+			fieldMap: map[string][]string{
+				"payload": {"log"},
+			},
+			redactions: []configv1alpha1.Redaction{
+				{Fields: []string{"payload.token"}},
+			},
+			expectedForbidden:  true,
+			expectedAuthorized: 1,
+		},
+		{
+			name:     "Sorting by a redacted field is rejected",
+			selector: "sortBy=payload.token",
+			redactions: []configv1alpha1.Redaction{
+				{Fields: []string{"payload.token"}},
+			},
+			expectedForbidden:  true,
+			expectedAuthorized: 1,
+		},
+		{
+			name:     "Selectors on any field but the metadata are rejected by redactions of the whole document",
+			selector: "metadata.namespace=default,payload.email=jane",
+			redactions: []configv1alpha1.Redaction{
+				{Patterns: []string{`[a-z]+@example\.com`}},
+			},
+			expectedForbidden:  true,
+			expectedAuthorized: 1,
+		},
+		{
+			name:     "Selectors on the metadata are accepted by redactions of the whole document",
+			selector: "metadata.namespace=default",
+			fieldMap: map[string][]string{
+				"metadata.namespace": {"kubernetes.namespace_name"},
+			},
+			redactions: []configv1alpha1.Redaction{
+				{Patterns: []string{`[a-z]+@example\.com`}},
+			},
+			expectedAuthorized: 1,
+		},
+		{
+			name:     "Selectors on redacted fields are accepted with access to the unredacted subresource",
+			selector: "payload.token=secret",
+			allowed:  true,
+			redactions: []configv1alpha1.Redaction{
+				{Fields: []string{"payload.token"}},
+			},
+			expectedAuthorized: 1,
+		},
+		{
+			name:     "Selectors on redacted fields are accepted for exempt groups without authorization",
+			selector: "payload.token=secret",
+			groups:   []string{"auditors"},
+			redactions: []configv1alpha1.Redaction{
+				{Fields: []string{"payload.token"}, ExemptGroups: []string{"auditors"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var authorized int
+			storage.Authorizer = authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+				authorized++
+				assert.Equal(t, storage.UnredactedSubresource, a.GetSubresource())
+				if test.allowed {
+					return authorizer.DecisionAllow, "", nil
+				}
+
+				return authorizer.DecisionNoOpinion, "", nil
+			})
+
+			defer func() {
+				storage.Authorizer = nil
+			}()
+
+			transport := &MockTransport{
+				responseBody: `{"hits":{"hits":[{"_id":"a","_source":{"payload":{"token":"secret"}}},{"_id":"b","_source":{"payload":{"token":"secret"}}}]}}`,
+			}
+
+			client, _ := elasticsearch.NewClient(elasticsearch.Config{Transport: transport})
+			dummy := &Dummy{}
+			codec, _, _ := srvstorage.NewStorageCodec(srvstorage.StorageCodecConfig{
+				StorageMediaType:  runtime.ContentTypeJSON,
+				StorageSerializer: serializer.NewCodecFactory(&runtime.Scheme{}),
+				Config:            storagebackend.Config{},
+			})
+
+			redactor, err := storage.NewRedactor(dummy.GetGroupVersionResource().GroupResource(), test.redactions, test.fieldMap)
+			assert.NilError(t, err)
+
+			opts := MakeDefaultOptions()
+			opts.FieldMap = test.fieldMap
+			opts.Redactor = redactor
+			restStorage := NewElasticsearchREST(dummy.GetGroupVersionResource().GroupResource(), codec, client, opts, true, dummy.New, dummy.NewList)
+
+			selector, err := labels.Parse(test.selector)
+			assert.NilError(t, err)
+
+			ctx := request.WithUser(context.TODO(), &user.DefaultInfo{Name: "jane", Groups: test.groups})
+			_, err = restStorage.(rest.Lister).List(ctx, &metainternalversion.ListOptions{
+				LabelSelector: selector,
+			})
+
+			if test.expectedForbidden {
+				assert.Assert(t, apierrors.IsForbidden(err), "unexpected error %v", err)
+			} else {
+				assert.NilError(t, err)
+			}
+
+			assert.Equal(t, test.expectedAuthorized, authorized)
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		name            string
+		redactQuery     bool
+		expectedIndices []string
+		expectedQuery   string
+	}{
+		{
+			name:            "Translated query and the indices of the namespace are resolved",
+			expectedIndices: []string{"logs-default.*"},
+			expectedQuery:   `{"_source":{"excludes":["kind","apiVersion"]},"query":{"bool":{"must":[{"bool":{"should":[{"match_phrase":{"payload.level":"error"}}]}},{"bool":{"should":[{"range":{"@timestamp":{"gte":"now-24h"}}}]}},{"bool":{"should":[{"match_phrase":{"metadata.namespace":"default"}}]}}],"must_not":[]}},"sort":[{"@timestamp":{"order":"asc","unmapped_type":"long"}}]}`,
+		},
+		{
+			name:            "Translated query is redacted",
+			redactQuery:     true,
+			expectedIndices: []string{"logs-default.*"},
+			expectedQuery:   "<redacted>",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := MakeDefaultOptions()
+			opts.Backend.Index = "logs-{namespace}.*"
+			opts.Backend.TimestampFields = []string{"@timestamp"}
+			opts.DefaultTimeRange = "now-24h"
+			opts.RedactQuery = test.redactQuery
+
+			restStorage := NewElasticsearchREST((&Dummy{}).GetGroupVersionResource().GroupResource(), nil, nil, opts, true, nil, nil)
+			selector, err := labels.Parse("payload.level=error")
+			assert.NilError(t, err)
+
+			ctx := request.WithNamespace(context.TODO(), "default")
+			indices, query, err := restStorage.(storage.QueryResolver).Query(ctx, &metainternalversion.ListOptions{
+				LabelSelector: selector,
+			})
+
+			assert.NilError(t, err)
+			assert.DeepEqual(t, test.expectedIndices, indices)
+			assert.Equal(t, test.expectedQuery, query)
+		})
+	}
+}
+
+func TestESClient(t *testing.T) {
+	tests := []struct {
+		name             string
+		maxRetries       int
+		failures         int
+		failureStatus    int
+		expectedStatus   int
+		expectedRequests int
+	}{
+		{
+			name:             "Internal server errors are retried",
+			failures:         2,
+			failureStatus:    http.StatusInternalServerError,
+			expectedStatus:   http.StatusOK,
+			expectedRequests: 3,
+		},
+		{
+			name:             "Too many requests are retried until the retries are exhausted",
+			maxRetries:       1,
+			failures:         3,
+			failureStatus:    http.StatusTooManyRequests,
+			expectedStatus:   http.StatusTooManyRequests,
+			expectedRequests: 2,
+		},
+		{
+			name:             "Retries are disabled",
+			maxRetries:       -1,
+			failures:         1,
+			failureStatus:    http.StatusServiceUnavailable,
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedRequests: 1,
+		},
+		{
+			name:             "Bad requests are not retried",
+			failures:         1,
+			failureStatus:    http.StatusBadRequest,
+			expectedStatus:   http.StatusBadRequest,
+			expectedRequests: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Elastic-Product", "Elasticsearch")
+				if r.URL.Path != "/_search" {
+					_, _ = w.Write([]byte(`{"version":{"number":"8.5.0"}}`))
+					return
+				}
+
+				requests++
+				if requests <= test.failures {
+					w.WriteHeader(test.failureStatus)
+				}
+
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			defer srv.Close()
+
+			backend := &configv1alpha1.Backend{
+				Elasticsearch: &configv1alpha1.BackendElasticsearch{
+					URL: []string{srv.URL},
+				},
+			}
+
+			backend.Elasticsearch.Connection.MaxRetries = test.maxRetries
+			backend.Elasticsearch.Connection.RetryBackoff = v1.Duration{Duration: time.Millisecond}
+
+			es, err := getESClient(backend)
+			assert.NilError(t, err)
+
+			// The client is shared by all apis of the same backend
+			shared, err := getESClient(backend.DeepCopy())
+			assert.NilError(t, err)
+			assert.Equal(t, es, shared)
+
+			other := backend.DeepCopy()
+			other.Elasticsearch.Connection.MaxRetries++
+			otherClient, err := getESClient(other)
+			assert.NilError(t, err)
+			assert.Assert(t, es != otherClient)
+
+			res, err := es.Search()
+			assert.NilError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, test.expectedStatus, res.StatusCode)
+			assert.Equal(t, test.expectedRequests, requests)
+		})
+	}
+}
+
+func TestQueryCache(t *testing.T) {
+	tests := []struct {
+		name             string
+		selector         string
+		expectedRequests int
+	}{
+		{
+			name:             "Queries with a time range in the past are cached",
+			selector:         "metadata.creationTimestamp>1666184400000,metadata.creationTimestamp<1666263600000",
+			expectedRequests: 1,
+		},
+		{
+			name:             "Queries without an upper bound are not cached",
+			selector:         "metadata.creationTimestamp>1666184400000",
+			expectedRequests: 2,
+		},
+		{
+			name:             "Queries bounded by the relative default time range are not cached",
+			selector:         "metadata.creationTimestamp<1666263600000",
+			expectedRequests: 2,
+		},
+		{
+			name:             "Queries with date math relative to now are not cached",
+			selector:         "metadata.creationTimestamp=now-1d",
+			expectedRequests: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests int
+			transport := &MockTransport{
+				middleware: func(req *http.Request, res *http.Response) {
+					requests++
+				},
+				responseBody: `{"hits":{"hits":[]}}`,
+			}
+
+			client, _ := elasticsearch.NewClient(elasticsearch.Config{Transport: transport})
+			dummy := &Dummy{}
+
+			opts := MakeDefaultOptions()
+			opts.Backend.CacheSize = 10
+			opts.FieldMap = map[string][]string{
+				"metadata.creationTimestamp": {"@timestamp"},
+			}
+
+			restStorage := NewElasticsearchREST(dummy.GetGroupVersionResource().GroupResource(), nil, client, opts, true, dummy.New, dummy.NewList)
+			selector, err := labels.Parse(test.selector)
+			assert.NilError(t, err)
+
+			for i := 0; i < 2; i++ {
+				_, err := restStorage.(rest.Lister).List(context.TODO(), &metainternalversion.ListOptions{
+					LabelSelector: selector,
+				})
+				assert.NilError(t, err)
+			}
+
+			assert.Equal(t, test.expectedRequests, requests)
+		})
+	}
+}
+
+func TestWatchStop(t *testing.T) {
+	tests := []struct {
+		name        string
+		refreshRate time.Duration
+		selector    string
+		stop        func(w watch.Interface, cancel context.CancelFunc)
+	}{
+		{
+			name: "Stream ends once all objects are consumed",
+			stop: func(w watch.Interface, cancel context.CancelFunc) {},
+		},
+		{
+			name:        "Stream sorted by another field than the timestamp ends once all objects are consumed",
+			refreshRate: time.Hour,
+			selector:    "sortBy=payload.level",
+			stop:        func(w watch.Interface, cancel context.CancelFunc) {},
+		},
+		{
+			name:        "Stream ends once it is stopped",
+			refreshRate: time.Hour,
+			stop: func(w watch.Interface, cancel context.CancelFunc) {
+				w.Stop()
+			},
+		},
+		{
+			name:        "Stream ends once the request context is cancelled",
+			refreshRate: time.Hour,
+			stop: func(w watch.Interface, cancel context.CancelFunc) {
+				cancel()
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transport := &MockTransport{
+				responseBody: `{"hits":{"hits":[]}}`,
+			}
+
+			client, _ := elasticsearch.NewClient(elasticsearch.Config{Transport: transport})
+			dummy := &Dummy{}
+
+			opts := MakeDefaultOptions()
+			opts.Backend.RefreshRate = test.refreshRate
+
+			restStorage := NewElasticsearchREST(dummy.GetGroupVersionResource().GroupResource(), nil, client, opts, true, dummy.New, dummy.NewList)
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+
+			selector, err := labels.Parse(test.selector)
+			assert.NilError(t, err)
+
+			w, err := restStorage.(rest.Watcher).Watch(ctx, &metainternalversion.ListOptions{
+				LabelSelector: selector,
+			})
+			assert.NilError(t, err)
+
+			test.stop(w, cancel)
+
+			select {
+			case _, ok := <-w.ResultChan():
+				assert.Equal(t, false, ok)
+			case <-time.After(5 * time.Second):
+				t.Fatal("result channel was not closed")
+			}
+		})
+	}
+}
+
+func TestDataStream(t *testing.T) {
+	settings := `{
+		".ds-logs-000001": {"settings": {"index.creation_date": "1666000000000", "index.routing.allocation.include._tier_preference": "data_frozen", "index.store.type": "snapshot"}},
+		".ds-logs-000003": {"settings": {"index.creation_date": "1666200000000", "index.routing.allocation.include._tier_preference": "data_hot"}},
+		".ds-logs-000002": {"settings": {"index.creation_date": "1666100000000", "index.routing.allocation.include._tier_preference": "data_cold,data_warm,data_hot"}}
+	}`
+
+	var manyIndices []string
+	for i := 0; i <= maxDateIndices; i++ {
+		manyIndices = append(manyIndices, fmt.Sprintf(`".ds-logs-%06d": {"settings": {"index.creation_date": "%d"}}`, i, 1666000000000+int64(i)*1000))
+	}
+
+	tests := []struct {
+		name             string
+		dataStream       string
+		namespace        string
+		clusters         []string
+		settings         string
+		selector         string
+		expectedSettings string
+		expectedRequest  string
+	}{
+		{
+			name:            "Backing indices rolled over before the time range are skipped",
+			selector:        "metadata.creationTimestamp>1666250000000",
+			expectedRequest: "/.ds-logs-000003/_search",
+		},
+		{
+			name:            "Search is routed through async search if it touches the cold tier",
+			selector:        "metadata.creationTimestamp>1666150000000",
+			expectedRequest: "/.ds-logs-000002,.ds-logs-000003/_async_search",
+		},
+		{
+			name:            "Search is routed through async search if it touches the frozen tier",
+			selector:        "metadata.creationTimestamp>1666050000000",
+			expectedRequest: "/.ds-logs-000001,.ds-logs-000002,.ds-logs-000003/_async_search",
+		},
+		{
+			name:             "Data streams are routed by namespace",
+			dataStream:       "logs-{namespace}",
+			namespace:        "team-a",
+			selector:         "metadata.creationTimestamp>1666250000000",
+			expectedSettings: "logs-team-a",
+			expectedRequest:  "/.ds-logs-000003/_search",
+		},
+		{
+			name:            "Data streams across all namespaces are queried by their pattern",
+			dataStream:      "logs-{namespace}",
+			selector:        "metadata.creationTimestamp>1666250000000",
+			expectedRequest: "/logs-*/_search",
+		},
+		{
+			name:             "Data streams of remote clusters are queried by their name",
+			clusters:         []string{"local", "eu-west"},
+			selector:         "metadata.creationTimestamp>1666250000000",
+			expectedSettings: "logs",
+			expectedRequest:  "/.ds-logs-000003,eu-west:logs/_search",
+		},
+		{
+			name:             "The data stream is queried if too many backing indices overlap the time range",
+			settings:         "{" + strings.Join(manyIndices, ",") + "}",
+			selector:         "metadata.creationTimestamp>1666000000000",
+			expectedSettings: "logs",
+			expectedRequest:  "/logs/_search",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.dataStream == "" {
+				test.dataStream = "logs"
+			}
+
+			if test.settings == "" {
+				test.settings = settings
+			}
+
+			var searchRequest, settingsRequest string
+			transport := &MockTransport{
+				middleware: func(req *http.Request, res *http.Response) {
+					if req.URL.Path == "/" {
+						res.Body = ioutil.NopCloser(strings.NewReader(`{"version":{"number":"8.5.0","build_flavor":"default"}}`))
+						return
+					}
+
+					if strings.HasSuffix(req.URL.Path, "/_settings/"+settingCreationDate+","+settingTierPreference+","+settingStoreType) {
+						settingsRequest = strings.Split(req.URL.Path, "/")[1]
+						res.Body = ioutil.NopCloser(strings.NewReader(test.settings))
+						return
+					}
+
+					searchRequest = req.URL.Path
+					if strings.HasSuffix(req.URL.Path, "/_async_search") {
+						res.Body = ioutil.NopCloser(strings.NewReader(`{"id":"search","is_running":false,"response":{"hits":{"hits":[]}}}`))
+					}
+				},
+				responseBody: `{"hits":{"hits":[]}}`,
+			}
+
+			client, _ := elasticsearch.NewClient(elasticsearch.Config{Transport: transport})
+			dummy := &Dummy{}
+
+			opts := MakeDefaultOptions()
+			opts.Backend.DataStream = test.dataStream
+			opts.Backend.Clusters = test.clusters
+			opts.Backend.AsyncSearch.Tiers = []string{tierCold, tierFrozen}
+			opts.FieldMap = map[string][]string{
+				"metadata.creationTimestamp": {"@timestamp"},
+			}
+
+			restStorage := NewElasticsearchREST(dummy.GetGroupVersionResource().GroupResource(), nil, client, opts, true, dummy.New, dummy.NewList)
+			selector, err := labels.Parse(test.selector)
+			assert.NilError(t, err)
+
+			_, err = restStorage.(rest.Lister).List(request.WithNamespace(context.TODO(), test.namespace), &metainternalversion.ListOptions{
+				LabelSelector: selector,
+			})
+
+			assert.NilError(t, err)
+			assert.Equal(t, test.expectedRequest, searchRequest)
+			if test.expectedSettings != "" {
+				assert.Equal(t, test.expectedSettings, settingsRequest)
+			}
+		})
+	}
+}
+
+func TestSearcher(t *testing.T) {
+	var requests []string
+	transport := &MockTransport{
+		middleware: func(req *http.Request, res *http.Response) {
+			requests = append(requests, req.Method+" "+req.URL.Path)
+
+			switch {
+			case req.URL.Path == "/":
+				res.Body = ioutil.NopCloser(strings.NewReader(`{"version":{"number":"8.5.0","build_flavor":"default"}}`))
+			case req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/_async_search"):
+				assert.Equal(t, "true", req.URL.Query().Get("keep_on_completion"))
+				assert.Equal(t, "500", req.URL.Query().Get("size"))
+				res.Body = ioutil.NopCloser(strings.NewReader(`{"id":"search","is_running":true}`))
+			case strings.HasSuffix(req.URL.Path, "/_search"):
+				b, _ := ioutil.ReadAll(req.Body)
+				assert.Assert(t, strings.Contains(string(b), `"search_after":[1666000000001]`), string(b))
+				assert.Equal(t, "1", req.URL.Query().Get("size"))
+				res.Body = ioutil.NopCloser(strings.NewReader(`{"hits":{"hits":[{"_id":"b","_source":{},"sort":[1666000000002]}]}}`))
+			case strings.HasPrefix(req.URL.Path, "/_async_search/status/"):
+				res.Body = ioutil.NopCloser(strings.NewReader(`{"id":"search","is_running":false,"expiration_time_in_millis":1666263600000,"completion_status":200}`))
+			case req.Method == http.MethodDelete:
+				res.StatusCode = http.StatusNotFound
+			}
+		},
+		responseBody: `{"id":"search","is_running":false,"response":{"hits":{"hits":[{"_id":"a","_source":{},"sort":[1666000000001]},{"_id":"b","_source":{},"sort":[1666000000002]}]}}}`,
+	}
+
+	client, _ := elasticsearch.NewClient(elasticsearch.Config{Transport: transport})
+	dummy := &Dummy{}
+	codec, _, _ := srvstorage.NewStorageCodec(srvstorage.StorageCodecConfig{
+		StorageMediaType:  runtime.ContentTypeJSON,
+		StorageSerializer: serializer.NewCodecFactory(&runtime.Scheme{}),
+		Config:            storagebackend.Config{},
+	})
+
+	restStorage := NewElasticsearchREST(dummy.GetGroupVersionResource().GroupResource(), codec, client, MakeDefaultOptions(), true, dummy.New, dummy.NewList)
+	searcher := restStorage.(storage.Searcher)
+
+	id, err := searcher.SubmitSearch(context.TODO(), &metainternalversion.ListOptions{
+		LabelSelector: labels.Everything(),
+		Limit:         500,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, "search", id)
+
+	progress, err := searcher.SearchProgress(context.TODO(), id)
+	assert.NilError(t, err)
+	assert.Equal(t, false, progress.Running)
+	assert.NilError(t, progress.Err)
+	assert.Equal(t, int64(1666263600000), progress.Expiration.UnixMilli())
+
+	list, err := searcher.SearchResults(context.TODO(), id, &metainternalversion.ListOptions{
+		LabelSelector: labels.Everything(),
+		Limit:         1,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(list.(*DummyList).Items))
+	assert.Equal(t, "a", string(list.(*DummyList).Items[0].UID))
+	assert.Equal(t, "[1666000000001]", list.(*DummyList).Continue)
+
+	list, err = searcher.SearchResults(context.TODO(), id, &metainternalversion.ListOptions{
+		LabelSelector: labels.Everything(),
+		Limit:         1,
+		Continue:      list.(*DummyList).Continue,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(list.(*DummyList).Items))
+	assert.Equal(t, "b", string(list.(*DummyList).Items[0].UID))
+
+	err = searcher.DeleteSearch(context.TODO(), id)
+	assert.Equal(t, true, apierrors.IsNotFound(err))
+
+	assert.DeepEqual(t, []string{
+		"GET /",
+		"POST /*/_async_search",
+		"GET /_async_search/status/search",
+		"GET /_async_search/search",
+		"POST /*/_search",
+		"DELETE /_async_search/search",
+	}, requests)
+}
+
+func TestClusterVersions(t *testing.T) {
+	tests := []struct {
+		name                string
+		info                string
+		hit                 string
+		compatibilityMode   bool
+		expectedPIT         bool
+		expectedAccept      string
+		expectedSearchAfter string
+	}{
+		{
+			name:                "Elasticsearch 7 watch streams use a PIT until they caught up",
+			info:                `{"name":"es-7","version":{"number":"7.17.7","build_flavor":"default","build_type":"docker"},"tagline":"You Know, for Search"}`,
+			hit:                 `{"_index":"logs","_type":"_doc","_id":"a","_source":{},"sort":[1666000000000,5]}`,
+			expectedPIT:         true,
+			expectedSearchAfter: `[1666000000000]`,
+		},
+		{
+			name:                "Compatibility headers are not sent to elasticsearch 7",
+			info:                `{"name":"es-7","version":{"number":"7.17.7","build_flavor":"default","build_type":"docker"},"tagline":"You Know, for Search"}`,
+			hit:                 `{"_index":"logs","_type":"_doc","_id":"a","_source":{},"sort":[1666000000000,5]}`,
+			compatibilityMode:   true,
+			expectedPIT:         true,
+			expectedAccept:      "application/json",
+			expectedSearchAfter: `[1666000000000]`,
+		},
+		{
+			name:                "PIT is not used with the oss distribution",
+			info:                `{"name":"es-7","version":{"number":"7.10.2","build_flavor":"oss","build_type":"docker"},"tagline":"You Know, for Search"}`,
+			hit:                 `{"_index":"logs","_type":"_doc","_id":"a","_source":{},"sort":[1666000000000]}`,
+			expectedSearchAfter: `[1666000000000]`,
+		},
+		{
+			name:                "Compatibility headers are sent to elasticsearch 8",
+			info:                `{"name":"es-8","version":{"number":"8.5.0","build_flavor":"default","build_type":"docker"},"tagline":"You Know, for Search"}`,
+			hit:                 `{"_index":"logs","_id":"a","_source":{},"sort":[1666000000000,5]}`,
+			compatibilityMode:   true,
+			expectedPIT:         true,
+			expectedAccept:      compatibilityMediaType,
+			expectedSearchAfter: `[1666000000000]`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				mu          sync.Mutex
+				pitOpened   bool
+				pitClosed   bool
+				accept      string
+				searchAfter = make(chan string, 1)
+				searches    int
+			)
+
+			transport := &MockTransport{
+				middleware: func(req *http.Request, res *http.Response) {
+					mu.Lock()
+					defer mu.Unlock()
+
+					switch {
+					case req.URL.Path == "/":
+						res.Body = ioutil.NopCloser(strings.NewReader(test.info))
+					case strings.HasSuffix(req.URL.Path, "/_pit") && req.Method == http.MethodPost:
+						pitOpened = true
+						res.Body = ioutil.NopCloser(strings.NewReader(`{"id":"pit"}`))
+					case req.URL.Path == "/_pit" && req.Method == http.MethodDelete:
+						pitClosed = true
+						res.Body = ioutil.NopCloser(strings.NewReader(`{"succeeded":true,"num_freed":1}`))
+					case strings.HasSuffix(req.URL.Path, "/_search"):
+						accept = req.Header.Get("Accept")
+						searches++
+
+						var body struct {
+							SearchAfter json.RawMessage `json:"search_after"`
+						}
+						assert.NilError(t, json.NewDecoder(req.Body).Decode(&body))
+
+						if searches == 1 {
+							res.Body = ioutil.NopCloser(strings.NewReader(`{"pit_id":"pit","hits":{"hits":[` + test.hit + `]}}`))
+							return
+						}
+
+						if searches == 2 {
+							searchAfter <- string(body.SearchAfter)
+						}
+
+						res.Body = ioutil.NopCloser(strings.NewReader(`{"hits":{"hits":[]}}`))
+					}
+				},
+			}
+
+			cluster := &cluster{}
+			client, err := elasticsearch.NewClient(elasticsearch.Config{
+				Transport:               &compatTransport{RoundTripper: transport, cluster: cluster},
+				EnableCompatibilityMode: test.compatibilityMode,
+			})
+			assert.NilError(t, err)
+
+			cluster.es = client
+			clustersMu.Lock()
+			clusters[client] = cluster
+			clustersMu.Unlock()
+
+			dummy := &Dummy{}
+			codec, _, _ := srvstorage.NewStorageCodec(srvstorage.StorageCodecConfig{
+				StorageMediaType:  runtime.ContentTypeJSON,
+				StorageSerializer: serializer.NewCodecFactory(&runtime.Scheme{}),
+				Config:            storagebackend.Config{},
+			})
+
+			opts := MakeDefaultOptions()
+			opts.Backend.RefreshRate = time.Millisecond
+			opts.Backend.BulkSize = 2
+
+			restStorage := NewElasticsearchREST(dummy.GetGroupVersionResource().GroupResource(), codec, client, opts, true, dummy.New, dummy.NewList)
+			w, err := restStorage.(rest.Watcher).Watch(context.TODO(), &metainternalversion.ListOptions{
+				LabelSelector: labels.Everything(),
+			})
+			assert.NilError(t, err)
+
+			event := <-w.ResultChan()
+			assert.Equal(t, watch.Added, event.Type)
+			assert.Equal(t, "a", string(event.Object.(*Dummy).UID))
+
+			select {
+			case v := <-searchAfter:
+				assert.Equal(t, test.expectedSearchAfter, v)
+			case <-time.After(5 * time.Second):
+				t.Fatal("stream did not continue after the first batch")
+			}
+
+			w.Stop()
+			for range w.ResultChan() {
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			assert.Equal(t, test.expectedPIT, pitOpened)
+			assert.Equal(t, test.expectedPIT, pitClosed)
+			assert.Equal(t, test.expectedAccept, accept)
+		})
+	}
+}
+
+func TestClusterVersionDetection(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+		fail     = true
+		release  = make(chan struct{})
+	)
+
+	c := &cluster{}
+	transport := &MockTransport{
+		middleware: func(req *http.Request, res *http.Response) {
+			// The cluster is not locked while the detection request is in flight
+			_ = c.name()
+			<-release
+
+			mu.Lock()
+			defer mu.Unlock()
+			requests++
+
+			if fail {
+				res.StatusCode = http.StatusServiceUnavailable
+				res.Status = "503 Service Unavailable"
+				return
+			}
+
+			res.Body = ioutil.NopCloser(strings.NewReader(`{"cluster_name":"central","version":{"number":"8.5.0","build_flavor":"default"}}`))
+		},
+	}
+
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Transport: transport, DisableRetry: true})
+	assert.NilError(t, err)
+	c.es = client
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Version(context.TODO())
+			errs <- err
+		}()
+	}
+
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.ErrorContains(t, err, "elasticsearch info failed with status 503")
+	}
+
+	assert.Equal(t, 1, requests)
+
+	r := &elasticsearchREST{cluster: c}
+	assert.Equal(t, false, r.supportsPIT(context.TODO()))
+	assert.Equal(t, false, r.supportsAsyncSearch(context.TODO()))
+	assert.Equal(t, 1, requests)
+
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+
+	c.mu.Lock()
+	c.failedAt = time.Now().Add(-detectRetryInterval)
+	c.mu.Unlock()
+
+	v, err := c.Version(context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, 8, v.Major)
+	assert.Equal(t, "central", c.name())
+	assert.Equal(t, true, r.supportsPIT(context.TODO()))
+	assert.Equal(t, true, r.supportsAsyncSearch(context.TODO()))
+	assert.Equal(t, 2, requests)
+}
+
+func TestCrossCluster(t *testing.T) {
+	transport := &MockTransport{
+		middleware: func(req *http.Request, res *http.Response) {
+			if req.URL.Path == "/" {
+				res.Body = ioutil.NopCloser(strings.NewReader(`{"cluster_name":"central","version":{"number":"8.5.0","build_flavor":"default"}}`))
+			}
+		},
+		responseBody: `{"hits":{"hits":[{"_index":"eu:audit-1","_id":"a","_source":{}},{"_index":"audit-1","_id":"b","_source":{}}]}}`,
+	}
+
+	client, _ := elasticsearch.NewClient(elasticsearch.Config{Transport: transport})
+	dummy := &Dummy{}
+	codec, _, _ := srvstorage.NewStorageCodec(srvstorage.StorageCodecConfig{
+		StorageMediaType:  runtime.ContentTypeJSON,
+		StorageSerializer: serializer.NewCodecFactory(&runtime.Scheme{}),
+		Config:            storagebackend.Config{},
+	})
+
+	opts := MakeDefaultOptions()
+	opts.Backend.Index = "audit-*"
+	opts.Backend.Clusters = []string{"local", "eu"}
+
+	restStorage := NewElasticsearchREST(dummy.GetGroupVersionResource().GroupResource(), codec, client, opts, true, dummy.New, dummy.NewList)
+	_, err := restStorage.(*elasticsearchREST).cluster.Version(context.TODO())
+	assert.NilError(t, err)
+
+	list, err := restStorage.(rest.Lister).List(context.TODO(), &metainternalversion.ListOptions{
+		LabelSelector: labels.Everything(),
+	})
+	assert.NilError(t, err)
+
+	items := list.(*DummyList).Items
+	assert.Equal(t, 2, len(items))
+	assert.Equal(t, "eu", items[0].Annotations[annotationESCluster])
+	assert.Equal(t, "central", items[1].Annotations[annotationESCluster])
+}
+
+// blockingTransport blocks each request until its context is done
+type blockingTransport struct{}
+
+func (t *blockingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	<-req.Context().Done()
+	return nil, req.Context().Err()
+}
+
+func TestListTimeout(t *testing.T) {
+	timeoutSeconds := int64(1)
+
+	tests := []struct {
+		name          string
+		ctx           func() (context.Context, context.CancelFunc)
+		listOpts      *metainternalversion.ListOptions
+		expectedError string
+	}{
+		{
+			name: "Requested timeout is exceeded",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.TODO())
+			},
+			listOpts: &metainternalversion.ListOptions{
+				LabelSelector:  labels.Everything(),
+				TimeoutSeconds: &timeoutSeconds,
+			},
+			expectedError: "Timeout: list request did not complete within 1s",
+		},
+		{
+			name: "Deadline of the request context is exceeded without a requested timeout",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.TODO(), 100*time.Millisecond)
+			},
+			listOpts: &metainternalversion.ListOptions{
+				LabelSelector: labels.Everything(),
+			},
+			expectedError: "Timeout: list request did not complete within the request deadline",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, _ := elasticsearch.NewClient(elasticsearch.Config{Transport: &blockingTransport{}, DisableRetry: true})
+			dummy := &Dummy{}
+
+			restStorage := NewElasticsearchREST(dummy.GetGroupVersionResource().GroupResource(), nil, client, MakeDefaultOptions(), true, dummy.New, dummy.NewList)
+			ctx, cancel := test.ctx()
+			defer cancel()
+
+			_, err := restStorage.(rest.Lister).List(ctx, test.listOpts)
+			assert.Error(t, err, test.expectedError)
+			assert.Equal(t, true, apierrors.IsTimeout(err))
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/raffis/kjournal/pkg/storage"
+)
+
+var _ storage.Searcher = &elasticsearchREST{}
+
+type esAsyncStatus struct {
+	ID                     string `json:"id"`
+	IsRunning              bool   `json:"is_running"`
+	ExpirationTimeInMillis int64  `json:"expiration_time_in_millis"`
+	CompletionStatus       int    `json:"completion_status"`
+}
+
+// SubmitSearch submits the list request to the async search api.
+// The async search collects the first page of the results, its size is given by the limit of the request.
+// The results are kept within elasticsearch until the keep alive of the async search expires.
+func (r *elasticsearchREST) SubmitSearch(ctx context.Context, options *metainternalversion.ListOptions) (string, error) {
+	if !r.supportsAsyncSearch(ctx) {
+		return "", fmt.Errorf("%w: elasticsearch cluster does not support async search", storage.ErrSearchNotSupported)
+	}
+
+	query, err := queryFromListOptions(ctx, options, r, r.opts.Redactor.ForRequest(ctx))
+	if apierrors.IsForbidden(err) {
+		return "", err
+	}
+
+	if err != nil {
+		return "", apierrors.NewBadRequest(err.Error())
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return "", err
+	}
+
+	async, err := r.submitAsyncSearch(ctx, &buf, r.indices(ctx, options), options.Limit, true)
+	if err != nil {
+		return "", err
+	}
+
+	return async.ID, nil
+}
+
+// SearchProgress returns the status of the async search
+func (r *elasticsearchREST) SearchProgress(ctx context.Context, id string) (storage.SearchProgress, error) {
+	var progress storage.SearchProgress
+	res, err := r.es.AsyncSearch.Status(id, r.es.AsyncSearch.Status.WithContext(ctx))
+	if err != nil {
+		return progress, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return progress, apierrors.NewNotFound(r.groupResource, id)
+	}
+
+	var status esAsyncStatus
+	if err := decodeResponse(res, &status); err != nil {
+		return progress, err
+	}
+
+	progress.Running = status.IsRunning
+	if status.ExpirationTimeInMillis > 0 {
+		progress.Expiration = time.UnixMilli(status.ExpirationTimeInMillis)
+	}
+
+	if status.CompletionStatus >= http.StatusBadRequest {
+		progress.Err = fmt.Errorf("elasticsearch search failed with status %d", status.CompletionStatus)
+	}
+
+	return progress, nil
+}
+
+// SearchResults returns a page of the results of the completed async search.
+// The first page is decoded from the stored async search, the following pages are queried
+// using search_after the same way as list requests continue.
+func (r *elasticsearchREST) SearchResults(ctx context.Context, id string, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	if options.Continue != "" {
+		return r.List(ctx, options)
+	}
+
+	async, err := r.getAsyncSearch(ctx, id, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	newListObj := r.NewList()
+	v, err := getListPrt(newListObj)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := async.Response.Hits.Hits
+	if options.Limit > 0 && int64(len(hits)) > options.Limit {
+		hits = hits[:options.Limit]
+	}
+
+	redactions := r.opts.Redactor.ForRequest(ctx)
+	for _, hit := range hits {
+		decodedObj, err := r.decodeFrom(ctx, hit, redactions)
+		if err != nil {
+			return nil, err
+		}
+
+		appendItem(v, decodedObj)
+	}
+
+	if len(hits) > 0 && len(hits[len(hits)-1].Sort) > 0 {
+		b, err := json.Marshal(hits[len(hits)-1].Sort)
+		if err != nil {
+			return newListObj, err
+		}
+
+		if err := r.metaAccessor.SetContinue(newListObj, string(b)); err != nil {
+			return newListObj, err
+		}
+	}
+
+	return newListObj, nil
+}
+
+// DeleteSearch deletes the async search and its results
+func (r *elasticsearchREST) DeleteSearch(ctx context.Context, id string) error {
+	res, err := r.es.AsyncSearch.Delete(id, r.es.AsyncSearch.Delete.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return apierrors.NewNotFound(r.groupResource, id)
+	}
+
+	if res.IsError() {
+		return fmt.Errorf("deleting async search failed with status %s", res.Status())
+	}
+
+	return nil
+}
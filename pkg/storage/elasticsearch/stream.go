@@ -3,15 +3,22 @@ package elasticsearch
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	statuserr "k8s.io/apimachinery/pkg/api/errors"
 	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/klog/v2"
+
+	"github.com/raffis/kjournal/pkg/storage"
 )
 
+const pitCloseTimeout = 10 * time.Second
+
 type pit struct {
 	ID string `json:"id"`
 }
@@ -21,120 +28,304 @@ type stream struct {
 	rest        *elasticsearchREST
 	refreshRate time.Duration
 	ch          chan watch.Event
-	done        chan bool
+	done        chan struct{}
+	cancel      context.CancelFunc
+	stopOnce    sync.Once
 	pit         pit
+	sortFields  int
+	redactions  *storage.Redactions
+}
+
+// batch is a result of the read ahead buffer
+type batch struct {
+	results esResults
+	err     error
+}
+
+func newStream(rest *elasticsearchREST, cancel context.CancelFunc) *stream {
+	return &stream{
+		refreshRate: rest.opts.Backend.RefreshRate,
+		rest:        rest,
+		ch:          make(chan watch.Event, rest.opts.Backend.BulkSize),
+		done:        make(chan struct{}),
+		cancel:      cancel,
+	}
 }
 
-func (s *stream) errorAndAbort(err error) {
+// send writes an event to the result channel unless the stream is stopped
+func (s *stream) send(ctx context.Context, event watch.Event) bool {
+	select {
+	case s.ch <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s *stream) errorAndAbort(ctx context.Context, err error) {
 	status := statuserr.NewBadRequest(err.Error()).Status()
-	s.ch <- watch.Event{
+	s.send(ctx, watch.Event{
 		Type:   watch.Error,
 		Object: &status,
-	}
+	})
 }
 
+// Start streams the objects to the result channel until all objects are consumed, the context is cancelled or the stream is stopped.
+// The result channel is closed once it returns.
 func (s *stream) Start(ctx context.Context, options *metainternalversion.ListOptions) {
+	resource := s.rest.groupResource.Resource
+	storage.WatchStreams.WithLabelValues(resource, backendName).Inc()
+
+	var sent int
+	defer func() {
+		s.cancel()
+		close(s.ch)
+		close(s.done)
+		storage.WatchStreams.WithLabelValues(resource, backendName).Dec()
+		storage.WatchStreamEvents.WithLabelValues(resource, backendName).Observe(float64(sent))
+	}()
+
 	if s.usePIT {
-		res, err := s.rest.es.OpenPointInTime([]string{s.rest.opts.Backend.Index}, "5m")
-		if err != nil {
-			s.errorAndAbort(err)
+		if err := s.openPIT(ctx, options); err != nil {
+			s.errorAndAbort(ctx, err)
 			return
 		}
 
-		if err := json.NewDecoder(res.Body).Decode(&s.pit); err != nil {
-			s.errorAndAbort(err)
-			return
-		}
+		defer s.closePIT()
 	}
 
 	var wg sync.WaitGroup
 	wg.Add(1)
-	batchResults := make(chan esResults, 2)
+	batches := make(chan batch, 2)
 
 	// read ahead buffer
 	go func() {
-		for {
-			klog.Info("start list query", "options", options)
-			query, err := queryFromListOptions(ctx, options, s.rest)
+		defer wg.Done()
+		defer close(batches)
+		s.readAhead(ctx, options, batches)
+	}()
+
+	// loop over batched results
+loop:
+	for batch := range batches {
+		if batch.err != nil {
+			s.errorAndAbort(ctx, batch.err)
+			break
+		}
+
+		for _, hit := range batch.results.Hits.Hits {
+			decodedObj, err := s.rest.decodeFrom(ctx, hit, s.redactions)
 			if err != nil {
-				return
+				klog.ErrorS(err, "failed to decode object", "index", hit.Index, "id", hit.ID)
+				continue
 			}
 
-			if s.pit.ID != "" {
-				query["pit"] = map[string]interface{}{
-					"id":         s.pit.ID,
-					"keep_alive": "5m",
-				}
+			if !s.send(ctx, watch.Event{
+				Type:   watch.Added,
+				Object: decodedObj,
+			}) {
+				break loop
 			}
 
-			esResults, err := s.rest.fetch(ctx, query, options)
-			if err != nil {
-				s.errorAndAbort(err)
-				return
-			}
+			sent++
+			storage.WatchEvents.WithLabelValues(resource, backendName).Inc()
+		}
+	}
 
-			batchResults <- esResults
+	// Stop the read ahead buffer in case the loop was aborted and drain it
+	s.cancel()
+	for range batches {
+	}
 
-			if len(esResults.Hits.Hits) != int(s.rest.opts.Backend.BulkSize) && s.refreshRate == 0 {
-				klog.Info("All objects consumed from stream")
-				s.done <- true
-				wg.Done()
-				break
+	wg.Wait()
+}
+
+// readAhead fetches the next batch while the previous one is consumed
+func (s *stream) readAhead(ctx context.Context, options *metainternalversion.ListOptions, batches chan<- batch) {
+	for {
+		klog.InfoS("start list query", "options", options)
+		esResults, err := s.fetchBatch(ctx, options)
+		if err != nil {
+			if ctx.Err() == nil {
+				batches <- batch{err: err}
 			}
 
-			if len(esResults.Hits.Hits) != int(s.rest.opts.Backend.BulkSize) {
-				klog.InfoS("wait for next check", "sleep", s.refreshRate.String())
-				time.Sleep(s.refreshRate)
+			return
+		}
+
+		select {
+		case batches <- batch{results: esResults}:
+		case <-ctx.Done():
+			return
+		}
+
+		if len(esResults.Hits.Hits) != int(s.rest.opts.Backend.BulkSize) && s.refreshRate == 0 {
+			klog.Info("All objects consumed from stream")
+			return
+		}
+
+		if len(esResults.Hits.Hits) != int(s.rest.opts.Backend.BulkSize) {
+			klog.InfoS("wait for next check", "sleep", s.refreshRate.String())
+			select {
+			case <-time.After(s.refreshRate):
+			case <-ctx.Done():
+				return
 			}
+		}
 
-			// The continue token represents the last sort value from the last hit.
-			// Which itself gets used in the next es query as search_after
-			// If there is no hit there will be no continue token as this means we reached the end of available results
-			if len(esResults.Hits.Hits) > 0 {
-				hit := esResults.Hits.Hits[len(esResults.Hits.Hits)-1]
-				if len(hit.Sort) > 0 {
-					b, err := json.Marshal(hit.Sort)
-					if err != nil {
-						s.errorAndAbort(err)
-						return
-					}
-
-					options.Continue = string(b)
+		// The continue token represents the last sort value from the last hit.
+		// Which itself gets used in the next es query as search_after
+		// If there is no hit there will be no continue token as this means we reached the end of available results
+		if len(esResults.Hits.Hits) > 0 {
+			hit := esResults.Hits.Hits[len(esResults.Hits.Hits)-1]
+			if len(hit.Sort) > 0 {
+				b, err := json.Marshal(hit.Sort)
+				if err != nil {
+					batches <- batch{err: err}
+					return
 				}
+
+				options.Continue = string(b)
 			}
+		}
 
-			// For the next search request the PIT from the previous search response needs to be taken as it can change over time
-			if s.pit.ID != "" {
-				s.pit.ID = esResults.PitID
+		// For the next search request the PIT from the previous search response needs to be taken as it can change over time
+		if s.pit.ID != "" && esResults.PitID != "" {
+			s.pit.ID = esResults.PitID
+		}
+
+		// A PIT is a frozen view of the indices, once the stream caught up it continues without it to receive new documents
+		if s.pit.ID != "" {
+			if len(esResults.Hits.Hits) != int(s.rest.opts.Backend.BulkSize) {
+				if err := s.releasePIT(options); err != nil {
+					batches <- batch{err: err}
+					return
+				}
 			}
 		}
+	}
+}
+
+// fetchBatch queries the next batch of the stream starting after the continue token
+func (s *stream) fetchBatch(ctx context.Context, options *metainternalversion.ListOptions) (results esResults, err error) {
+	ctx, span := startSpan(ctx, "elasticsearch.watch.batch",
+		attribute.String("kjournal.resource", s.rest.groupResource.Resource),
+		attribute.String("kjournal.continue", options.Continue),
+		attribute.Bool("elasticsearch.pit", s.pit.ID != ""),
+	)
+
+	defer func() {
+		endSpan(span, err)
 	}()
 
-	// loop over batched results
-	for esResults := range batchResults {
-		var hit esHit
-		for _, hit = range esResults.Hits.Hits {
-			decodedObj, err := s.rest.decodeFrom(hit)
-			if err != nil {
-				break
-			}
+	query, err := queryFromListOptions(ctx, options, s.rest, s.redactions)
+	if err != nil {
+		return results, err
+	}
 
-			s.ch <- watch.Event{
-				Type:   watch.Added,
-				Object: decodedObj,
-			}
+	if sort, ok := query["sort"].([]map[string]interface{}); ok {
+		s.sortFields = len(sort)
+	}
+
+	if s.pit.ID != "" {
+		query["pit"] = map[string]interface{}{
+			"id":         s.pit.ID,
+			"keep_alive": "5m",
 		}
 	}
 
-	wg.Wait()
+	results, err = s.rest.fetch(ctx, query, options)
+	if err != nil {
+		return results, err
+	}
+
+	span.SetAttributes(attribute.Int("kjournal.hits", len(results.Hits.Hits)))
+	return results, nil
 }
 
-func (s *stream) Stop() {
-	_, err := s.rest.es.ClosePointInTime()
+func (s *stream) openPIT(ctx context.Context, options *metainternalversion.ListOptions) error {
+	res, err := s.rest.es.OpenPointInTime(s.rest.indices(ctx, options), "5m",
+		s.rest.es.OpenPointInTime.WithContext(ctx),
+		s.rest.es.OpenPointInTime.WithIgnoreUnavailable(s.rest.ignoreUnavailable()),
+	)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("opening point in time failed with status %s", res.Status())
+	}
+
+	return json.NewDecoder(res.Body).Decode(&s.pit)
+}
+
+// closePIT releases the point in time.
+// The request context is likely cancelled already, hence the pit is closed using a separate context.
+func (s *stream) closePIT() {
+	if s.pit.ID == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pitCloseTimeout)
+	defer cancel()
+
+	b, err := json.Marshal(s.pit)
 	if err != nil {
 		klog.ErrorS(err, "failed to close pit")
+		return
+	}
+
+	res, err := s.rest.es.ClosePointInTime(
+		s.rest.es.ClosePointInTime.WithContext(ctx),
+		s.rest.es.ClosePointInTime.WithBody(strings.NewReader(string(b))),
+	)
+
+	if err != nil {
+		klog.ErrorS(err, "failed to close pit")
+		return
+	}
+
+	res.Body.Close()
+}
+
+// releasePIT closes the PIT and strips the implicit tiebreaker from the continue token.
+// Searches using a PIT are sorted by _shard_doc in addition to the sort fields of the query.
+func (s *stream) releasePIT(options *metainternalversion.ListOptions) error {
+	s.closePIT()
+	s.pit.ID = ""
+
+	if options.Continue == "" {
+		return nil
+	}
+
+	var searchAfter []interface{}
+	if err := json.Unmarshal([]byte(options.Continue), &searchAfter); err != nil {
+		return fmt.Errorf("failed to decode continue token: %w", err)
+	}
+
+	if len(searchAfter) <= s.sortFields {
+		return nil
+	}
+
+	if s.sortFields == 0 {
+		options.Continue = ""
+		return nil
 	}
 
+	b, err := json.Marshal(searchAfter[:s.sortFields])
+	if err != nil {
+		return err
+	}
+
+	options.Continue = string(b)
+	return nil
+}
+
+// Stop cancels all backend queries of the stream, the result channel is closed once the stream has ended
+func (s *stream) Stop() {
+	s.stopOnce.Do(s.cancel)
 }
 
 func (s *stream) ResultChan() <-chan watch.Event {
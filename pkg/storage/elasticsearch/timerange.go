@@ -0,0 +1,263 @@
+package elasticsearch
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// dateMathUnits holds the fixed length date math units, calendar units (y, M, w, d) are handled by addDateMath
+var dateMathUnits = map[byte]time.Duration{
+	'h': time.Hour,
+	'H': time.Hour,
+	'm': time.Minute,
+	's': time.Second,
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// TimeRange resolves the time range of a request from the selectors on the timestamp fields.
+// If there is no lower bound the DefaultTimeRange is used, the upper bound defaults to now.
+func (r *elasticsearchREST) TimeRange(ctx context.Context, options *metainternalversion.ListOptions) (time.Time, time.Time, error) {
+	return r.timeRange(r.requirements(options), time.Now())
+}
+
+// requirements returns the selector requirements of the request including the static filter
+func (r *elasticsearchREST) requirements(options *metainternalversion.ListOptions) labels.Requirements {
+	var requirements labels.Requirements
+	if options.LabelSelector != nil {
+		requirements, _ = options.LabelSelector.Requirements()
+	}
+
+	return append(requirements, r.opts.Filter...)
+}
+
+func (r *elasticsearchREST) timeRange(requirements labels.Requirements, now time.Time) (from time.Time, to time.Time, err error) {
+	var hasFrom bool
+	to = now
+
+	for _, req := range requirements {
+		if !r.isTimestampField(req.Key()) || req.Values().Len() == 0 {
+			continue
+		}
+
+		ts, err := parseTime(req.Values().UnsortedList()[0], now)
+		if err != nil {
+			return from, to, err
+		}
+
+		switch req.Operator() {
+		case selection.GreaterThan:
+			if !hasFrom || ts.After(from) {
+				from = ts
+				hasFrom = true
+			}
+		case selection.LessThan:
+			if ts.Before(to) {
+				to = ts
+			}
+		case selection.Equals, selection.DoubleEquals:
+			from, to = ts, ts
+			hasFrom = true
+		}
+	}
+
+	if !hasFrom && r.opts.DefaultTimeRange != "" {
+		from, err = parseTime(r.opts.DefaultTimeRange, now)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid default time range: %w", err)
+		}
+	}
+
+	return from, to, nil
+}
+
+// hasLowerBound returns true if a selector bounds the timestamp fields from below
+func (r *elasticsearchREST) hasLowerBound(requirements labels.Requirements) bool {
+	for _, req := range requirements {
+		if !r.isTimestampField(req.Key()) || req.Values().Len() == 0 {
+			continue
+		}
+
+		switch req.Operator() {
+		case selection.GreaterThan, selection.Equals, selection.DoubleEquals:
+			return true
+		}
+	}
+
+	return false
+}
+
+// isTimestampField returns true if the selector key is mapped to any of the backend timestamp fields
+func (r *elasticsearchREST) isTimestampField(key string) bool {
+	fields := []string{key}
+
+	for field, fieldsTo := range r.opts.FieldMap {
+		for _, fieldTo := range fieldsTo {
+			lookupKey := strings.TrimLeft(strings.Replace(key, field, fieldTo, -1), ".")
+			if lookupKey != key {
+				fields = append(fields, lookupKey)
+			}
+		}
+	}
+
+	for _, field := range fields {
+		for _, tsField := range r.opts.Backend.TimestampFields {
+			if field == tsField {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// parseTime parses a timestamp as used in selectors.
+// Supported are epoch milliseconds, elasticsearch date math relative to now (e.g. now-24h/d) and dates.
+func parseTime(value string, now time.Time) (time.Time, error) {
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+
+	// elasticsearch evaluates date math in UTC as no time_zone is passed to the range queries
+	if strings.HasPrefix(value, "now") {
+		return parseDateMath(strings.TrimPrefix(value, "now"), now.UTC())
+	}
+
+	for _, layout := range timeLayouts {
+		if ts, err := time.Parse(layout, value); err == nil {
+			return ts, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unsupported time format %s", value)
+}
+
+// parseDateMath applies elasticsearch date math expressions like -1d/d to the given time.
+// Calendar units are applied within the location of the given time.
+func parseDateMath(expr string, ts time.Time) (time.Time, error) {
+	for len(expr) > 0 {
+		op := expr[0]
+		expr = expr[1:]
+
+		if op == '/' {
+			if len(expr) == 0 {
+				return ts, fmt.Errorf("missing rounding unit")
+			}
+
+			rounded, err := roundDateMath(ts, expr[0])
+			if err != nil {
+				return ts, err
+			}
+
+			ts = rounded
+			expr = expr[1:]
+			continue
+		}
+
+		if op != '+' && op != '-' {
+			return ts, fmt.Errorf("unsupported date math operator %c", op)
+		}
+
+		i := 0
+		for i < len(expr) && expr[i] >= '0' && expr[i] <= '9' {
+			i++
+		}
+
+		n := 1
+		if i > 0 {
+			n, _ = strconv.Atoi(expr[:i])
+		}
+
+		if i >= len(expr) {
+			return ts, fmt.Errorf("missing date math unit")
+		}
+
+		if op == '-' {
+			n = -n
+		}
+
+		added, err := addDateMath(ts, n, expr[i])
+		if err != nil {
+			return ts, err
+		}
+
+		ts = added
+		expr = expr[i+1:]
+	}
+
+	return ts, nil
+}
+
+// addDateMath adds n date math units to the given time, calendar units respect the varying length of months and years.
+// Like in elasticsearch the day is clamped to the last day of the resulting month, hence now-1M on March 31 is February 28.
+func addDateMath(ts time.Time, n int, unit byte) (time.Time, error) {
+	switch unit {
+	case 'y':
+		return addMonths(ts, 12*n), nil
+	case 'M':
+		return addMonths(ts, n), nil
+	case 'w':
+		return ts.AddDate(0, 0, 7*n), nil
+	case 'd':
+		return ts.AddDate(0, 0, n), nil
+	}
+
+	d, ok := dateMathUnits[unit]
+	if !ok {
+		return ts, fmt.Errorf("unsupported date math unit %c", unit)
+	}
+
+	return ts.Add(time.Duration(n) * d), nil
+}
+
+// addMonths adds n months to the given time and clamps the day to the last day of the resulting month
+func addMonths(ts time.Time, n int) time.Time {
+	year, month, day := ts.Date()
+	hour, min, sec := ts.Clock()
+
+	lastDay := time.Date(year, month+time.Month(n)+1, 0, 0, 0, 0, 0, ts.Location()).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return time.Date(year, month+time.Month(n), day, hour, min, sec, ts.Nanosecond(), ts.Location())
+}
+
+// roundDateMath rounds the given time down to the start of the date math unit.
+// Rounding down is what elasticsearch does for lt while gt rounds up,
+// the resolved time range is therefore never narrower than the one matched by elasticsearch.
+// Weeks start on monday like in elasticsearch.
+func roundDateMath(ts time.Time, unit byte) (time.Time, error) {
+	year, month, day := ts.Date()
+	hour, min, sec := ts.Clock()
+
+	switch unit {
+	case 'y':
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, ts.Location()), nil
+	case 'M':
+		return time.Date(year, month, 1, 0, 0, 0, 0, ts.Location()), nil
+	case 'w':
+		return time.Date(year, month, day-(int(ts.Weekday())+6)%7, 0, 0, 0, 0, ts.Location()), nil
+	case 'd':
+		return time.Date(year, month, day, 0, 0, 0, 0, ts.Location()), nil
+	case 'h', 'H':
+		return time.Date(year, month, day, hour, 0, 0, 0, ts.Location()), nil
+	case 'm':
+		return time.Date(year, month, day, hour, min, 0, 0, ts.Location()), nil
+	case 's':
+		return time.Date(year, month, day, hour, min, sec, 0, ts.Location()), nil
+	}
+
+	return ts, fmt.Errorf("unsupported date math unit %c", unit)
+}
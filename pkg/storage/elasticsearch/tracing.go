@@ -0,0 +1,54 @@
+package elasticsearch
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/raffis/kjournal/pkg/storage/elasticsearch"
+
+// startSpan starts a child span of the request span.
+// The tracer provider is taken from the parent span, there won't be any spans if apiserver tracing is disabled.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return trace.SpanFromContext(ctx).TracerProvider().Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan records the error if any and ends the span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// queryAttribute returns the translated backend query unless redacted
+func (r *elasticsearchREST) queryAttribute(query []byte) attribute.KeyValue {
+	return attribute.String("kjournal.query", r.queryString(query))
+}
+
+// queryString returns the translated backend query unless redacted
+func (r *elasticsearchREST) queryString(query []byte) string {
+	if r.opts.RedactQuery {
+		return "<redacted>"
+	}
+
+	return string(query)
+}
+
+// traceTransport propagates the trace context to elasticsearch with traceparent headers
+type traceTransport struct {
+	http.RoundTripper
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	propagation.TraceContext{}.Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+	return t.RoundTripper.RoundTrip(req)
+}
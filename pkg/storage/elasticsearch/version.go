@@ -0,0 +1,285 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	elasticsearch "github.com/elastic/go-elasticsearch/v8"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// compatibilityMediaType is sent by the v8 client if the compatibility mode is enabled, elasticsearch 7 rejects it
+	compatibilityMediaType = "application/vnd.elasticsearch+json;compatible-with=8"
+
+	// buildFlavorOSS is the build flavor of the elasticsearch distributions without x-pack features such as point in time or async search
+	buildFlavorOSS = "oss"
+
+	// detectRetryInterval is the time a failed version detection is cached before the cluster is requested again
+	detectRetryInterval = 10 * time.Second
+)
+
+var (
+	// minVersion is the minimum supported elasticsearch version, older versions do not send the product header required by the client
+	minVersion = esVersion{Major: 7, Minor: 14}
+
+	// pitVersion is the first elasticsearch version supporting point in time
+	pitVersion = esVersion{Major: 7, Minor: 10}
+
+	// asyncSearchVersion is the first elasticsearch version supporting async search
+	asyncSearchVersion = esVersion{Major: 7, Minor: 7}
+)
+
+var (
+	clustersMu sync.Mutex
+	clusters   = make(map[*elasticsearch.Client]*cluster)
+)
+
+type esInfo struct {
+	ClusterName string `json:"cluster_name"`
+	Version     struct {
+		Number      string `json:"number"`
+		BuildFlavor string `json:"build_flavor"`
+	} `json:"version"`
+}
+
+type esVersion struct {
+	Number      string
+	Major       int
+	Minor       int
+	BuildFlavor string
+}
+
+func parseVersion(number, buildFlavor string) (esVersion, error) {
+	v := esVersion{
+		Number:      number,
+		BuildFlavor: buildFlavor,
+	}
+
+	parts := strings.SplitN(number, ".", 3)
+	if len(parts) < 2 {
+		return v, fmt.Errorf("invalid elasticsearch version %s", number)
+	}
+
+	var err error
+	if v.Major, err = strconv.Atoi(parts[0]); err != nil {
+		return v, fmt.Errorf("%w: invalid elasticsearch version %s", err, number)
+	}
+
+	if v.Minor, err = strconv.Atoi(parts[1]); err != nil {
+		return v, fmt.Errorf("%w: invalid elasticsearch version %s", err, number)
+	}
+
+	return v, nil
+}
+
+func (v esVersion) atLeast(min esVersion) bool {
+	return v.Major > min.Major || (v.Major == min.Major && v.Minor >= min.Minor)
+}
+
+// supported returns an error if the version is older than the minimum supported version
+func (v esVersion) supported() error {
+	if !v.atLeast(minVersion) {
+		return fmt.Errorf("elasticsearch %s is not supported, at least %d.%d is required", v.Number, minVersion.Major, minVersion.Minor)
+	}
+
+	return nil
+}
+
+// supportsPIT returns true if point in time can be used
+func (v esVersion) supportsPIT() bool {
+	return v.atLeast(pitVersion) && v.BuildFlavor != buildFlavorOSS
+}
+
+// supportsAsyncSearch returns true if the async search api is available
+func (v esVersion) supportsAsyncSearch() bool {
+	return v.atLeast(asyncSearchVersion) && v.BuildFlavor != buildFlavorOSS
+}
+
+// cluster holds the detected version of the elasticsearch cluster behind a client.
+// The version is detected once and shared by all apis using the same client.
+// A failed detection is cached for the detectRetryInterval so an unreachable cluster is not requested by every api call.
+type cluster struct {
+	es          *elasticsearch.Client
+	mu          sync.Mutex
+	version     *esVersion
+	clusterName string
+	err         error
+	failedAt    time.Time
+	detecting   chan struct{}
+}
+
+// getCluster returns the cluster of the client
+func getCluster(es *elasticsearch.Client) *cluster {
+	clustersMu.Lock()
+	defer clustersMu.Unlock()
+
+	if c, ok := clusters[es]; ok {
+		return c
+	}
+
+	c := &cluster{es: es}
+	if es != nil {
+		clusters[es] = c
+	}
+
+	return c
+}
+
+// Version returns the version of the cluster, it is detected on the first call.
+// Concurrent callers wait for a single detection request, the lock is not held while the cluster is requested.
+func (c *cluster) Version(ctx context.Context) (esVersion, error) {
+	for {
+		c.mu.Lock()
+		if c.version != nil {
+			v := *c.version
+			c.mu.Unlock()
+			return v, nil
+		}
+
+		if c.err != nil && time.Since(c.failedAt) < detectRetryInterval {
+			err := c.err
+			c.mu.Unlock()
+			return esVersion{}, err
+		}
+
+		if c.detecting == nil {
+			break
+		}
+
+		detecting := c.detecting
+		c.mu.Unlock()
+
+		select {
+		case <-detecting:
+		case <-ctx.Done():
+			return esVersion{}, ctx.Err()
+		}
+	}
+
+	detecting := make(chan struct{})
+	c.detecting = detecting
+	c.mu.Unlock()
+
+	info, err := c.detect(ctx)
+	var v esVersion
+	if err == nil {
+		v, err = parseVersion(info.Version.Number, info.Version.BuildFlavor)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer close(detecting)
+	c.detecting = nil
+
+	// A cancelled request does not tell anything about the cluster
+	if err != nil && ctx.Err() == nil {
+		c.err = err
+		c.failedAt = time.Now()
+	}
+
+	if err != nil {
+		return esVersion{}, err
+	}
+
+	klog.InfoS("detected elasticsearch version", "cluster", info.ClusterName, "version", v.Number, "flavor", v.BuildFlavor)
+	c.version = &v
+	c.clusterName = info.ClusterName
+	c.err = nil
+	return v, nil
+}
+
+// name returns the name of the cluster, it is empty as long as the cluster was not detected
+func (c *cluster) name() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.clusterName
+}
+
+// detect requests the cluster info.
+// The request is sent by the transport directly as the client rejects responses of versions without the product header.
+func (c *cluster) detect(ctx context.Context) (info esInfo, err error) {
+	if c.es == nil {
+		return info, fmt.Errorf("no elasticsearch client configured")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	if err != nil {
+		return info, err
+	}
+
+	res, err := c.es.Transport.Perform(req)
+	if err != nil {
+		return info, fmt.Errorf("%w: failed to request elasticsearch info", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return info, fmt.Errorf("elasticsearch info failed with status %s", res.Status)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&info); err != nil {
+		return info, fmt.Errorf("%w: failed to decode elasticsearch info", err)
+	}
+
+	return info, nil
+}
+
+// compatTransport removes the v8 compatibility headers from requests to elasticsearch 7 clusters.
+// The client sends them if the compatibility mode is enabled using ELASTIC_CLIENT_APIVERSIONING.
+type compatTransport struct {
+	http.RoundTripper
+	cluster *cluster
+}
+
+func (t *compatTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept") != compatibilityMediaType {
+		return t.RoundTripper.RoundTrip(req)
+	}
+
+	// The version detection request itself is sent without compatibility headers
+	v, err := t.cluster.Version(req.Context())
+	if err != nil {
+		klog.ErrorS(err, "failed to detect elasticsearch version")
+		return t.RoundTripper.RoundTrip(req)
+	}
+
+	if v.Major < 8 {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept", "application/json")
+		if req.Header.Get("Content-Type") == compatibilityMediaType {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	}
+
+	return t.RoundTripper.RoundTrip(req)
+}
+
+// supportsPIT returns true if the cluster supports point in time, it is not used as long as the version is unknown
+func (r *elasticsearchREST) supportsPIT(ctx context.Context) bool {
+	v, err := r.cluster.Version(ctx)
+	if err != nil {
+		klog.ErrorS(err, "failed to detect elasticsearch version")
+		return false
+	}
+
+	return v.supportsPIT()
+}
+
+// supportsAsyncSearch returns true if the cluster supports the async search api, it is not used as long as the version is unknown
+func (r *elasticsearchREST) supportsAsyncSearch(ctx context.Context) bool {
+	v, err := r.cluster.Version(ctx)
+	if err != nil {
+		klog.ErrorS(err, "failed to detect elasticsearch version")
+		return false
+	}
+
+	return v.supportsAsyncSearch()
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/apiserver/pkg/server/healthz"
+
+	configv1alpha1 "github.com/raffis/kjournal/pkg/apis/config/v1alpha1"
+)
+
+// BindingCheck verifies that the storage of an api binding resolves within the storage backend,
+// e.g. that an index pattern matches any indices
+type BindingCheck func(ctx context.Context, backend *configv1alpha1.Backend, apiBinding *configv1alpha1.API) error
+
+// HealthChecker probes the storage backend and the api bindings served from it
+type HealthChecker interface {
+	// Backend returns the name of the storage backend
+	Backend() string
+
+	// Check verifies that the storage backend is reachable
+	Check(ctx context.Context) error
+
+	// Bindings returns the api bindings which are currently served
+	Bindings() []*configv1alpha1.API
+
+	// CheckBinding verifies that the storage of the api binding resolves
+	CheckBinding(ctx context.Context, apiBinding *configv1alpha1.API) error
+}
+
+// Health is the result of probing the storage backend
+type Health struct {
+	Backend   string
+	Latency   time.Duration
+	ProbeTime time.Time
+	Err       error
+	Bindings  []BindingHealth
+}
+
+// BindingHealth is the result of probing the storage of an api binding
+type BindingHealth struct {
+	Resource string
+	Err      error
+}
+
+// Probe checks the storage backend and all api bindings.
+// The api bindings are only checked if the backend is reachable.
+func Probe(ctx context.Context, checker HealthChecker) Health {
+	health := Health{
+		Backend:   checker.Backend(),
+		ProbeTime: time.Now(),
+	}
+
+	health.Err = checker.Check(ctx)
+	health.Latency = time.Since(health.ProbeTime)
+
+	if health.Err != nil {
+		return health
+	}
+
+	for _, apiBinding := range checker.Bindings() {
+		health.Bindings = append(health.Bindings, BindingHealth{
+			Resource: apiBinding.Resource,
+			Err:      checker.CheckBinding(ctx, apiBinding),
+		})
+	}
+
+	return health
+}
+
+// ReadyzChecks returns the readiness checks for the storage backend.
+// Api bindings which do not resolve, e.g. an index pattern without any indices yet, do not affect the readiness
+// as other apis are still served. They are reported by the backendstatuses resource and the LogSource conditions instead.
+func ReadyzChecks(checker HealthChecker) []healthz.HealthChecker {
+	return []healthz.HealthChecker{
+		healthz.NamedCheck("storage-backend", func(r *http.Request) error {
+			if err := checker.Check(r.Context()); err != nil {
+				return fmt.Errorf("%w: storage backend %s is not reachable", err, checker.Backend())
+			}
+
+			return nil
+		}),
+	}
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	configv1alpha1 "github.com/raffis/kjournal/pkg/apis/config/v1alpha1"
+	corev1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+)
+
+// fakeHealthChecker reports the configured errors for the backend and its api bindings
+type fakeHealthChecker struct {
+	err         error
+	bindings    []*configv1alpha1.API
+	bindingErrs map[string]error
+	checked     []string
+}
+
+func (c *fakeHealthChecker) Backend() string {
+	return "fake"
+}
+
+func (c *fakeHealthChecker) Check(ctx context.Context) error {
+	return c.err
+}
+
+func (c *fakeHealthChecker) Bindings() []*configv1alpha1.API {
+	return c.bindings
+}
+
+func (c *fakeHealthChecker) CheckBinding(ctx context.Context, apiBinding *configv1alpha1.API) error {
+	c.checked = append(c.checked, apiBinding.Resource)
+	return c.bindingErrs[apiBinding.Resource]
+}
+
+func TestBindingsCheck(t *testing.T) {
+	bindings := []*configv1alpha1.API{
+		{Resource: "containerlogs"},
+		{Resource: "auditevents"},
+	}
+
+	tests := []struct {
+		name             string
+		checker          *fakeHealthChecker
+		expectedReady    bool
+		expectedChecked  []string
+		expectedStatuses []corev1alpha1.APIStatus
+	}{
+		{
+			name: "Resolved bindings are reported",
+			checker: &fakeHealthChecker{
+				bindings: bindings,
+			},
+			expectedReady:   true,
+			expectedChecked: []string{"containerlogs", "auditevents"},
+			expectedStatuses: []corev1alpha1.APIStatus{
+				{Resource: "containerlogs", Resolved: true},
+				{Resource: "auditevents", Resolved: true},
+			},
+		},
+		{
+			name: "Unresolved bindings are reported without affecting the readiness",
+			checker: &fakeHealthChecker{
+				bindings: bindings,
+				bindingErrs: map[string]error{
+					"auditevents": errors.New("index pattern audit-* does not match any indices"),
+				},
+			},
+			expectedReady:   true,
+			expectedChecked: []string{"containerlogs", "auditevents"},
+			expectedStatuses: []corev1alpha1.APIStatus{
+				{Resource: "containerlogs", Resolved: true},
+				{Resource: "auditevents", Resolved: false, Error: "index pattern audit-* does not match any indices"},
+			},
+		},
+		{
+			name: "Bindings are not checked if the backend is not reachable",
+			checker: &fakeHealthChecker{
+				err:      errors.New("connection refused"),
+				bindings: bindings,
+			},
+			expectedReady: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+			assert.NilError(t, err)
+
+			for _, check := range ReadyzChecks(test.checker) {
+				err := check.Check(req)
+				assert.Equal(t, test.expectedReady, err == nil, "check %s: unexpected error %v", check.Name(), err)
+			}
+
+			obj, err := NewBackendStatusStorage(test.checker).(rest.Getter).Get(context.TODO(), "fake", nil)
+			assert.NilError(t, err)
+
+			status := obj.(*corev1alpha1.BackendStatus).Status
+			assert.Equal(t, test.expectedReady, status.Reachable)
+			assert.DeepEqual(t, test.expectedStatuses, status.APIs)
+			assert.DeepEqual(t, test.expectedChecked, test.checker.checked)
+		})
+	}
+}
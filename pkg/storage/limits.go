@@ -0,0 +1,357 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	configv1alpha1 "github.com/raffis/kjournal/pkg/apis/config/v1alpha1"
+)
+
+const (
+	// userLimiterCacheSize is the maximum number of users whose limiter state is kept
+	userLimiterCacheSize = 4096
+
+	// userLimiterTTL is the time the limiter state of an idle user is kept
+	userLimiterTTL = 15 * time.Minute
+)
+
+// TimeRangeResolver is implemented by storages which can resolve the time range a request queries
+type TimeRangeResolver interface {
+	TimeRange(ctx context.Context, options *metainternalversion.ListOptions) (from time.Time, to time.Time, err error)
+}
+
+var _ rest.Scoper = &limitedStorage{}
+var _ rest.Storage = &limitedStorage{}
+var _ rest.Lister = &limitedStorage{}
+var _ rest.Watcher = &limitedStorage{}
+var _ rest.TableConvertor = &limitedStorage{}
+var _ Searcher = &limitedStorage{}
+
+// limitedStorage enforces the configured limits per requesting user before delegating to the backend storage
+type limitedStorage struct {
+	storage       rest.Storage
+	groupResource schema.GroupResource
+	limits        configv1alpha1.Limits
+	mu            sync.Mutex
+	users         *cache.LRUExpireCache
+	watches       map[string]int32
+}
+
+// userLimiter holds the rate limiter of a user, it is keyed by the user and its groups as the groups determine the limits
+type userLimiter struct {
+	name    string
+	spec    configv1alpha1.LimitSpec
+	limiter *rate.Limiter
+}
+
+// WithLimits wraps the storage with the given limits
+func WithLimits(storage rest.Storage, groupResource schema.GroupResource, limits *configv1alpha1.Limits) rest.Storage {
+	if limits == nil {
+		return storage
+	}
+
+	return &limitedStorage{
+		storage:       storage,
+		groupResource: groupResource,
+		limits:        *limits,
+		users:         cache.NewLRUExpireCache(userLimiterCacheSize),
+		watches:       make(map[string]int32),
+	}
+}
+
+func (s *limitedStorage) New() runtime.Object {
+	return s.storage.New()
+}
+
+func (s *limitedStorage) Destroy() {
+	s.storage.Destroy()
+}
+
+func (s *limitedStorage) NewList() runtime.Object {
+	if lister, ok := s.storage.(rest.Lister); ok {
+		return lister.NewList()
+	}
+
+	return nil
+}
+
+func (s *limitedStorage) NamespaceScoped() bool {
+	if scoper, ok := s.storage.(rest.Scoper); ok {
+		return scoper.NamespaceScoped()
+	}
+
+	return false
+}
+
+func (s *limitedStorage) List(ctx context.Context, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	lister, ok := s.storage.(rest.Lister)
+	if !ok {
+		return nil, apierrors.NewMethodNotSupported(s.groupResource, "list")
+	}
+
+	u := s.user(ctx)
+	if err := s.admit(ctx, u, options); err != nil {
+		return nil, err
+	}
+
+	if err := s.limit(u, options); err != nil {
+		return nil, err
+	}
+
+	return lister.List(ctx, options)
+}
+
+func (s *limitedStorage) Watch(ctx context.Context, options *metainternalversion.ListOptions) (watch.Interface, error) {
+	watcher, ok := s.storage.(rest.Watcher)
+	if !ok {
+		return nil, apierrors.NewMethodNotSupported(s.groupResource, "watch")
+	}
+
+	u := s.user(ctx)
+	if err := s.admit(ctx, u, options); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if u.spec.MaxConcurrentWatches > 0 && s.watches[u.name] >= u.spec.MaxConcurrentWatches {
+		s.mu.Unlock()
+		return nil, apierrors.NewTooManyRequests(fmt.Sprintf("the maximum of %d concurrent watches for %s is reached", u.spec.MaxConcurrentWatches, s.groupResource.String()), 1)
+	}
+
+	s.watches[u.name]++
+	s.mu.Unlock()
+
+	w, err := watcher.Watch(ctx, options)
+	if err != nil {
+		s.releaseWatch(u)
+		return nil, err
+	}
+
+	return &limitedWatch{
+		Interface: w,
+		release: func() {
+			s.releaseWatch(u)
+		},
+	}, nil
+}
+
+// SubmitSearch enforces the limits before the search is submitted to the backend storage
+func (s *limitedStorage) SubmitSearch(ctx context.Context, options *metainternalversion.ListOptions) (string, error) {
+	searcher, err := searcherOf(s.storage, s.groupResource)
+	if err != nil {
+		return "", err
+	}
+
+	u := s.user(ctx)
+	if err := s.admit(ctx, u, options); err != nil {
+		return "", err
+	}
+
+	if err := s.limit(u, options); err != nil {
+		return "", err
+	}
+
+	return searcher.SubmitSearch(ctx, options)
+}
+
+func (s *limitedStorage) SearchProgress(ctx context.Context, id string) (SearchProgress, error) {
+	searcher, err := searcherOf(s.storage, s.groupResource)
+	if err != nil {
+		return SearchProgress{}, err
+	}
+
+	return searcher.SearchProgress(ctx, id)
+}
+
+func (s *limitedStorage) SearchResults(ctx context.Context, id string, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	searcher, err := searcherOf(s.storage, s.groupResource)
+	if err != nil {
+		return nil, err
+	}
+
+	return searcher.SearchResults(ctx, id, options)
+}
+
+func (s *limitedStorage) DeleteSearch(ctx context.Context, id string) error {
+	searcher, err := searcherOf(s.storage, s.groupResource)
+	if err != nil {
+		return err
+	}
+
+	return searcher.DeleteSearch(ctx, id)
+}
+
+func (s *limitedStorage) ConvertToTable(ctx context.Context, obj runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	if convertor, ok := s.storage.(rest.TableConvertor); ok {
+		return convertor.ConvertToTable(ctx, obj, tableOptions)
+	}
+
+	return rest.NewDefaultTableConvertor(s.groupResource).ConvertToTable(ctx, obj, tableOptions)
+}
+
+// Query resolves the backend query from the backend storage
+func (s *limitedStorage) Query(ctx context.Context, options *metainternalversion.ListOptions) ([]string, string, error) {
+	if resolver, ok := s.storage.(QueryResolver); ok {
+		return resolver.Query(ctx, options)
+	}
+
+	return nil, "", fmt.Errorf("%s does not support query resolution", s.groupResource.String())
+}
+
+// TimeRange resolves the time range from the backend storage
+func (s *limitedStorage) TimeRange(ctx context.Context, options *metainternalversion.ListOptions) (time.Time, time.Time, error) {
+	if resolver, ok := s.storage.(TimeRangeResolver); ok {
+		return resolver.TimeRange(ctx, options)
+	}
+
+	return time.Time{}, time.Time{}, fmt.Errorf("%s does not support time ranges", s.groupResource.String())
+}
+
+// admit verifies the rate limit and the requested time range
+func (s *limitedStorage) admit(ctx context.Context, u *userLimiter, options *metainternalversion.ListOptions) error {
+	if u.limiter != nil && !u.limiter.Allow() {
+		return apierrors.NewTooManyRequests(fmt.Sprintf("rate limit of %d requests per second for %s exceeded", u.spec.QPS, s.groupResource.String()), 1)
+	}
+
+	if u.spec.MaxTimeRange.Duration == 0 {
+		return nil
+	}
+
+	resolver, ok := s.storage.(TimeRangeResolver)
+	if !ok {
+		return nil
+	}
+
+	from, to, err := resolver.TimeRange(ctx, options)
+	if err != nil {
+		return apierrors.NewBadRequest(fmt.Sprintf("failed to determine the requested time range: %s", err.Error()))
+	}
+
+	if to.Sub(from) > u.spec.MaxTimeRange.Duration {
+		return apierrors.NewBadRequest(fmt.Sprintf("requested time range of %s exceeds the maximum time range of %s, narrow down the time range using timestamp field selectors",
+			to.Sub(from).Truncate(time.Second).String(), u.spec.MaxTimeRange.Duration.String()))
+	}
+
+	return nil
+}
+
+// limit enforces the maximum limit, it is used as limit if the request has none
+func (s *limitedStorage) limit(u *userLimiter, options *metainternalversion.ListOptions) error {
+	if u.spec.MaxLimit == 0 {
+		return nil
+	}
+
+	if options.Limit > u.spec.MaxLimit {
+		return apierrors.NewBadRequest(fmt.Sprintf("limit %d exceeds the maximum limit of %d", options.Limit, u.spec.MaxLimit))
+	}
+
+	if options.Limit == 0 {
+		options.Limit = u.spec.MaxLimit
+	}
+
+	return nil
+}
+
+// user returns the limiter state of the requesting user.
+// The state expires once the user is idle, a change of the groups of the user resolves the limits again.
+func (s *limitedStorage) user(ctx context.Context) *userLimiter {
+	var name string
+	var groups []string
+
+	if info, ok := request.UserFrom(ctx); ok {
+		name = info.GetName()
+		groups = info.GetGroups()
+	} else {
+		name = user.Anonymous
+	}
+
+	groups = append([]string(nil), groups...)
+	sort.Strings(groups)
+	key := name + "/" + strings.Join(groups, ",")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u, ok := s.users.Get(key); ok {
+		s.users.Add(key, u, userLimiterTTL)
+		return u.(*userLimiter)
+	}
+
+	spec := s.spec(name, groups)
+	u := &userLimiter{
+		name: name,
+		spec: spec,
+	}
+
+	if spec.QPS > 0 {
+		burst := int(spec.Burst)
+		if burst == 0 {
+			burst = int(spec.QPS)
+		}
+
+		u.limiter = rate.NewLimiter(rate.Limit(spec.QPS), burst)
+	}
+
+	s.users.Add(key, u, userLimiterTTL)
+	return u
+}
+
+// spec returns the limits which apply to the given user
+func (s *limitedStorage) spec(name string, groups []string) configv1alpha1.LimitSpec {
+	for _, override := range s.limits.Overrides {
+		for _, u := range override.Users {
+			if u == name {
+				return override.LimitSpec
+			}
+		}
+
+		for _, g := range override.Groups {
+			for _, group := range groups {
+				if g == group {
+					return override.LimitSpec
+				}
+			}
+		}
+	}
+
+	return s.limits.LimitSpec
+}
+
+// releaseWatch releases a watch slot, the watches are counted per user independent of the cached limiter state
+func (s *limitedStorage) releaseWatch(u *userLimiter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.watches[u.name]--
+	if s.watches[u.name] <= 0 {
+		delete(s.watches, u.name)
+	}
+}
+
+// limitedWatch releases the watch slot of the user once the watch is stopped
+type limitedWatch struct {
+	watch.Interface
+	once    sync.Once
+	release func()
+}
+
+func (w *limitedWatch) Stop() {
+	w.once.Do(w.release)
+	w.Interface.Stop()
+}
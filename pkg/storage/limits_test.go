@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	configv1alpha1 "github.com/raffis/kjournal/pkg/apis/config/v1alpha1"
+)
+
+var testGroupResource = schema.GroupResource{Group: "core.kjournal", Resource: "containerlogs"}
+
+// fakeStorage is a backend storage which records the requests it receives
+type fakeStorage struct {
+	from    time.Time
+	to      time.Time
+	indices []string
+	query   string
+	items   int
+	listed  []*metainternalversion.ListOptions
+	watches []*watch.FakeWatcher
+	err     error
+}
+
+func (s *fakeStorage) New() runtime.Object {
+	return &metav1.PartialObjectMetadata{}
+}
+
+func (s *fakeStorage) NewList() runtime.Object {
+	return &metav1.PartialObjectMetadataList{}
+}
+
+func (s *fakeStorage) Destroy() {}
+
+func (s *fakeStorage) NamespaceScoped() bool {
+	return true
+}
+
+func (s *fakeStorage) List(ctx context.Context, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	s.listed = append(s.listed, options)
+	list := &metav1.PartialObjectMetadataList{}
+	for i := 0; i < s.items; i++ {
+		list.Items = append(list.Items, metav1.PartialObjectMetadata{})
+	}
+
+	return list, s.err
+}
+
+func (s *fakeStorage) Watch(ctx context.Context, options *metainternalversion.ListOptions) (watch.Interface, error) {
+	w := watch.NewFakeWithChanSize(s.items, false)
+	for i := 0; i < s.items; i++ {
+		w.Add(&metav1.PartialObjectMetadata{})
+	}
+
+	s.watches = append(s.watches, w)
+	return w, s.err
+}
+
+func (s *fakeStorage) ConvertToTable(ctx context.Context, obj runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	return rest.NewDefaultTableConvertor(testGroupResource).ConvertToTable(ctx, obj, tableOptions)
+}
+
+func (s *fakeStorage) TimeRange(ctx context.Context, options *metainternalversion.ListOptions) (time.Time, time.Time, error) {
+	return s.from, s.to, nil
+}
+
+func (s *fakeStorage) Query(ctx context.Context, options *metainternalversion.ListOptions) ([]string, string, error) {
+	return s.indices, s.query, nil
+}
+
+func withUser(name string, groups ...string) context.Context {
+	return request.WithUser(context.TODO(), &user.DefaultInfo{Name: name, Groups: groups})
+}
+
+func TestLimits(t *testing.T) {
+	now := time.Now()
+
+	type call struct {
+		ctx           context.Context
+		watch         bool
+		stop          bool
+		limit         int64
+		expectedLimit int64
+		expectedError func(err error) bool
+	}
+
+	tests := []struct {
+		name   string
+		limits configv1alpha1.Limits
+		from   time.Time
+		calls  []call
+	}{
+		{
+			name: "Requests exceeding the rate limit are rejected",
+			limits: configv1alpha1.Limits{
+				LimitSpec: configv1alpha1.LimitSpec{QPS: 1, Burst: 1},
+			},
+			calls: []call{
+				{ctx: withUser("a")},
+				{ctx: withUser("a"), expectedError: apierrors.IsTooManyRequests},
+				{ctx: withUser("b")},
+			},
+		},
+		{
+			name: "Requests exceeding the maximum time range are rejected",
+			limits: configv1alpha1.Limits{
+				LimitSpec: configv1alpha1.LimitSpec{MaxTimeRange: metav1.Duration{Duration: time.Hour}},
+			},
+			from: now.Add(-2 * time.Hour),
+			calls: []call{
+				{ctx: withUser("a"), expectedError: apierrors.IsBadRequest},
+				{ctx: withUser("a"), watch: true, expectedError: apierrors.IsBadRequest},
+			},
+		},
+		{
+			name: "Maximum limit is enforced and used as default limit",
+			limits: configv1alpha1.Limits{
+				LimitSpec: configv1alpha1.LimitSpec{MaxLimit: 100},
+			},
+			calls: []call{
+				{ctx: withUser("a"), expectedLimit: 100},
+				{ctx: withUser("a"), limit: 50, expectedLimit: 50},
+				{ctx: withUser("a"), limit: 101, expectedError: apierrors.IsBadRequest},
+			},
+		},
+		{
+			name: "Overrides of a group replace the default limits",
+			limits: configv1alpha1.Limits{
+				LimitSpec: configv1alpha1.LimitSpec{MaxLimit: 100},
+				Overrides: []configv1alpha1.LimitOverride{
+					{Groups: []string{"admins"}, LimitSpec: configv1alpha1.LimitSpec{MaxLimit: 1000}},
+				},
+			},
+			calls: []call{
+				{ctx: withUser("a", "admins"), limit: 500, expectedLimit: 500},
+				{ctx: withUser("a"), limit: 500, expectedError: apierrors.IsBadRequest},
+				{ctx: withUser("a", "admins"), limit: 500, expectedLimit: 500},
+			},
+		},
+		{
+			name: "Watches exceeding the maximum concurrent watches are rejected until a watch is stopped",
+			limits: configv1alpha1.Limits{
+				LimitSpec: configv1alpha1.LimitSpec{MaxConcurrentWatches: 1},
+			},
+			calls: []call{
+				{ctx: withUser("a"), watch: true},
+				{ctx: withUser("a"), watch: true, expectedError: apierrors.IsTooManyRequests},
+				{ctx: withUser("a", "other"), watch: true, expectedError: apierrors.IsTooManyRequests},
+				{ctx: withUser("b"), watch: true},
+				{ctx: withUser("a"), watch: true, stop: true},
+				{ctx: withUser("a"), watch: true, expectedError: apierrors.IsTooManyRequests},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend := &fakeStorage{from: test.from, to: now}
+			limits := test.limits
+			storage := WithLimits(backend, testGroupResource, &limits)
+
+			var watches []watch.Interface
+			for i, c := range test.calls {
+				var err error
+				options := &metainternalversion.ListOptions{Limit: c.limit}
+
+				if c.stop {
+					for _, w := range watches {
+						w.Stop()
+					}
+
+					watches = nil
+				}
+
+				if c.watch {
+					var w watch.Interface
+					w, err = storage.(rest.Watcher).Watch(c.ctx, options)
+					if err == nil {
+						watches = append(watches, w)
+					}
+				} else {
+					_, err = storage.(rest.Lister).List(c.ctx, options)
+				}
+
+				if c.expectedError != nil {
+					assert.Assert(t, c.expectedError(err), "call %d: unexpected error %v", i, err)
+					continue
+				}
+
+				assert.NilError(t, err, "call %d", i)
+				if !c.watch && c.expectedLimit != 0 {
+					assert.Equal(t, c.expectedLimit, options.Limit)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	metricsNamespace = "kjournal"
+	metricsSubsystem = "storage"
+)
+
+var (
+	// QueryDuration tracks the latency of backend queries
+	QueryDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      metricsSubsystem,
+			Name:           "query_duration_seconds",
+			Help:           "Latency of queries against the storage backend by resource and backend.",
+			Buckets:        []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource", "backend"},
+	)
+
+	// QueryErrors counts failed backend queries
+	QueryErrors = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      metricsSubsystem,
+			Name:           "query_errors_total",
+			Help:           "Number of failed queries against the storage backend by resource and backend.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource", "backend"},
+	)
+
+	// QueryHits tracks the number of hits returned by a single backend query
+	QueryHits = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      metricsSubsystem,
+			Name:           "query_hits",
+			Help:           "Number of hits returned per query by resource and backend.",
+			Buckets:        metrics.ExponentialBuckets(1, 4, 8),
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource", "backend"},
+	)
+
+	// DecodeErrors counts backend documents which could not be decoded into an api object
+	DecodeErrors = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      metricsSubsystem,
+			Name:           "decode_errors_total",
+			Help:           "Number of backend documents which failed to decode by resource and backend.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource", "backend"},
+	)
+
+	// WatchStreams tracks the currently open watch streams
+	WatchStreams = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      metricsSubsystem,
+			Name:           "watch_streams",
+			Help:           "Number of active watch streams by resource and backend.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource", "backend"},
+	)
+
+	// WatchEvents counts the events sent to watch streams
+	WatchEvents = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      metricsSubsystem,
+			Name:           "watch_events_total",
+			Help:           "Number of events sent to watch streams by resource and backend.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource", "backend"},
+	)
+
+	// WatchStreamEvents tracks the number of events sent per watch stream once it is closed
+	WatchStreamEvents = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      metricsSubsystem,
+			Name:           "watch_stream_events",
+			Help:           "Number of events sent per watch stream by resource and backend.",
+			Buckets:        metrics.ExponentialBuckets(1, 4, 10),
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource", "backend"},
+	)
+)
+
+// The metrics are exposed on the apiserver metrics endpoint
+func init() {
+	legacyregistry.MustRegister(QueryDuration)
+	legacyregistry.MustRegister(QueryErrors)
+	legacyregistry.MustRegister(QueryHits)
+	legacyregistry.MustRegister(DecodeErrors)
+	legacyregistry.MustRegister(WatchStreams)
+	legacyregistry.MustRegister(WatchEvents)
+	legacyregistry.MustRegister(WatchStreamEvents)
+}
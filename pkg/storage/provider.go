@@ -1,12 +1,16 @@
 package storage
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sync"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apiserver/pkg/registry/generic"
 	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/klog/v2"
 	"sigs.k8s.io/apiserver-runtime/pkg/builder/resource"
 
 	configv1alpha1 "github.com/raffis/kjournal/pkg/apis/config/v1alpha1"
@@ -17,28 +21,47 @@ var (
 	ErrUnsupportedBackend = errors.New("unsupported backend")
 	ErrNameInvalid        = errors.New("invalid key provided")
 	Providers             utils.Registry[RestProvider]
+	Checks                utils.Registry[BackendCheck]
+	BindingChecks         utils.Registry[BindingCheck]
 )
 
 func init() {
 	Providers = utils.NewRegistry[RestProvider]()
+	Checks = utils.NewRegistry[BackendCheck]()
+	BindingChecks = utils.NewRegistry[BindingCheck]()
 }
 
 type RestProvider func(obj resource.Object, scheme *runtime.Scheme, getter generic.RESTOptionsGetter, backend *configv1alpha1.Backend, apiBinding *configv1alpha1.API) (rest.Storage, error)
 
+// BackendCheck verifies that the configured storage backend is reachable
+type BackendCheck func(ctx context.Context, backend *configv1alpha1.Backend) error
+
 type Provider interface {
+	HealthChecker
+	SearchProvider
 	Provide(obj resource.Object, scheme *runtime.Scheme, getter generic.RESTOptionsGetter) (rest.Storage, error)
 }
 
 type provider struct {
 	backend      *configv1alpha1.Backend
+	backendType  string
 	restProvider RestProvider
+	check        BackendCheck
+	bindingCheck BindingCheck
 	apiRegistry  utils.Registry[*configv1alpha1.API]
+	apis         []*configv1alpha1.API
+	accessLog    AccessLogger
+	clusterName  string
+	mu           sync.RWMutex
+	searchers    map[string]Searcher
 }
 
 func NewProvider(conf configv1alpha1.APIServerConfig) (Provider, error) {
 	p := &provider{
 		backend:     &conf.Backend,
 		apiRegistry: utils.NewRegistry[*configv1alpha1.API](),
+		clusterName: conf.ClusterName,
+		searchers:   make(map[string]Searcher),
 	}
 
 	t, err := getType(conf.Backend)
@@ -52,12 +75,27 @@ func NewProvider(conf configv1alpha1.APIServerConfig) (Provider, error) {
 	}
 
 	p.restProvider = provider
+	p.backendType = t
+
+	if check, err := Checks.Get(t); err == nil {
+		p.check = check
+	}
+
+	if check, err := BindingChecks.Get(t); err == nil {
+		p.bindingCheck = check
+	}
+
+	if p.accessLog, err = NewAccessLogger(conf.AccessLog); err != nil {
+		return nil, err
+	}
 
 	for _, v := range conf.Apis {
 		apiBinding := v
 		if err := p.apiRegistry.Add(apiBinding.Resource, &apiBinding); err != nil {
 			return nil, err
 		}
+
+		p.apis = append(p.apis, &apiBinding)
 	}
 
 	return p, nil
@@ -74,7 +112,86 @@ func (p *provider) Provide(obj resource.Object, scheme *runtime.Scheme, getter g
 		return nil, fmt.Errorf("%w: no api binding found for %s", err, key)
 	}
 
-	return p.restProvider(obj, scheme, getter, p.backend, apiBinding)
+	storage, err := p.restProvider(obj, scheme, getter, p.backend, withDefaultCluster(apiBinding, p.clusterName))
+	if err != nil {
+		return nil, err
+	}
+
+	storage, searcher := wrapStorage(storage, obj.GetGroupVersionResource().GroupResource(), apiBinding, p.accessLog)
+	if searcher != nil {
+		p.mu.Lock()
+		p.searchers[key] = searcher
+		p.mu.Unlock()
+	}
+
+	return storage, nil
+}
+
+// wrapStorage applies the search threshold, the limits and the access log to the backend storage.
+// The threshold is applied within the limits so that list requests submitted as search are limited as well.
+// The returned searcher is nil if the backend storage does not support searches,
+// otherwise it submits searches through the limits and the access log.
+func wrapStorage(storage rest.Storage, groupResource schema.GroupResource, apiBinding *configv1alpha1.API, accessLog AccessLogger) (rest.Storage, Searcher) {
+	_, searchable := storage.(Searcher)
+	storage = WithAccessLog(WithLimits(WithSearchThreshold(storage, groupResource, apiBinding.AsyncSearchThreshold), groupResource, apiBinding.Limits), groupResource, accessLog)
+
+	if !searchable {
+		return storage, nil
+	}
+
+	return storage, storage.(Searcher)
+}
+
+func (p *provider) Searcher(resource string) (Searcher, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if searcher, ok := p.searchers[resource]; ok {
+		return searcher, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrSearchNotSupported, resource)
+}
+
+func (p *provider) Backend() string {
+	return p.backendType
+}
+
+func (p *provider) Check(ctx context.Context) error {
+	if p.check == nil {
+		return nil
+	}
+
+	return p.check(ctx, p.backend)
+}
+
+func (p *provider) Bindings() []*configv1alpha1.API {
+	return p.apis
+}
+
+func (p *provider) CheckBinding(ctx context.Context, apiBinding *configv1alpha1.API) error {
+	if p.bindingCheck == nil {
+		return nil
+	}
+
+	return p.bindingCheck(ctx, p.backend, apiBinding)
+}
+
+// withDefaultCluster scopes apis with a cluster field to the serving cluster unless they define a default cluster.
+// The serving cluster is the configured clusterName, kubernetes does not expose a cluster name which could be detected.
+func withDefaultCluster(apiBinding *configv1alpha1.API, clusterName string) *configv1alpha1.API {
+	if apiBinding.ClusterField == "" || apiBinding.DefaultCluster != "" {
+		return apiBinding
+	}
+
+	if clusterName == "" {
+		klog.InfoS("no clusterName configured, requests without a cluster selector query all clusters", "resource", apiBinding.Resource, "clusterField", apiBinding.ClusterField)
+		return apiBinding
+	}
+
+	binding := apiBinding.DeepCopy()
+	binding.DefaultCluster = clusterName
+	return binding
 }
 
 func getType(conf configv1alpha1.Backend) (string, error) {
@@ -0,0 +1,350 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Jeffail/gabs"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+
+	configv1alpha1 "github.com/raffis/kjournal/pkg/apis/config/v1alpha1"
+)
+
+const (
+	// UnredactedSubresource is the virtual subresource which grants access to unredacted objects
+	UnredactedSubresource = "unredacted"
+
+	redactionMask = "****"
+)
+
+var (
+	ErrInvalidRedaction = errors.New("invalid redaction")
+
+	// Authorizer is used to verify access to the unredacted subresource.
+	// It is set once the apiserver is configured, without it only the group based exemptions apply.
+	Authorizer authorizer.Authorizer
+)
+
+// Redactor redacts sensitive data from objects based on the requesting user
+type Redactor struct {
+	groupResource schema.GroupResource
+	fieldMap      map[string][]string
+	rules         []redactionRule
+}
+
+type redactionRule struct {
+	configv1alpha1.Redaction
+	patterns []*regexp.Regexp
+	// paths are the redacted fields and the backend paths they are mapped to
+	paths []string
+	key   []byte
+}
+
+// NewRedactor validates the redactions and returns a redactor for the given resource.
+// The field map of the resource is used to resolve the backend paths of the redacted fields.
+// It returns nil if there are no redactions.
+func NewRedactor(groupResource schema.GroupResource, redactions []configv1alpha1.Redaction, fieldMap map[string][]string) (*Redactor, error) {
+	if len(redactions) == 0 {
+		return nil, nil
+	}
+
+	r := &Redactor{
+		groupResource: groupResource,
+		fieldMap:      fieldMap,
+	}
+
+	for i, redaction := range redactions {
+		rule := redactionRule{
+			Redaction: redaction,
+		}
+
+		switch rule.Action {
+		case "":
+			rule.Action = configv1alpha1.RedactionActionMask
+		case configv1alpha1.RedactionActionDrop, configv1alpha1.RedactionActionMask, configv1alpha1.RedactionActionHash:
+		default:
+			return nil, fmt.Errorf("%w: unsupported action %s in redaction %d", ErrInvalidRedaction, rule.Action, i)
+		}
+
+		if len(rule.Fields) == 0 && len(rule.Patterns) == 0 {
+			return nil, fmt.Errorf("%w: redaction %d requires fields or patterns", ErrInvalidRedaction, i)
+		}
+
+		if rule.Action == configv1alpha1.RedactionActionHash {
+			if rule.HashKeyFile == "" {
+				return nil, fmt.Errorf("%w: redaction %d requires a hashKeyFile for the hash action", ErrInvalidRedaction, i)
+			}
+
+			key, err := os.ReadFile(rule.HashKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("%w: failed to read the hash key of redaction %d: %s", ErrInvalidRedaction, i, err.Error())
+			}
+
+			rule.key = []byte(strings.TrimSpace(string(key)))
+			if len(rule.key) == 0 {
+				return nil, fmt.Errorf("%w: the hash key of redaction %d is empty", ErrInvalidRedaction, i)
+			}
+		}
+
+		for _, field := range rule.Fields {
+			rule.paths = append(rule.paths, backendPaths(field, fieldMap)...)
+		}
+
+		for _, pattern := range rule.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid pattern in redaction %d: %s", ErrInvalidRedaction, i, err.Error())
+			}
+
+			rule.patterns = append(rule.patterns, re)
+		}
+
+		r.rules = append(r.rules, rule)
+	}
+
+	return r, nil
+}
+
+// Redactions are the redaction rules which apply to a requester
+type Redactions struct {
+	fieldMap map[string][]string
+	rules    []redactionRule
+}
+
+// ForRequest resolves the redactions which apply to the requesting user.
+// It should be called once per request as it authorizes the access to the unredacted subresource.
+// It returns nil if nothing is redacted for the user.
+func (r *Redactor) ForRequest(ctx context.Context) *Redactions {
+	if r == nil {
+		return nil
+	}
+
+	u, ok := request.UserFrom(ctx)
+	if !ok {
+		u = &user.DefaultInfo{Name: user.Anonymous}
+	}
+
+	var rules []redactionRule
+	for _, rule := range r.rules {
+		if rule.appliesTo(u) {
+			rules = append(rules, rule)
+		}
+	}
+
+	if len(rules) == 0 || r.unredacted(ctx, u) {
+		return nil
+	}
+
+	return &Redactions{fieldMap: r.fieldMap, rules: rules}
+}
+
+// Redact applies the redactions to the json document
+func (r *Redactions) Redact(doc *gabs.Container) error {
+	if r == nil {
+		return nil
+	}
+
+	for _, rule := range r.rules {
+		if err := rule.apply(doc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Redacted returns true if the field or any of its nested fields is redacted.
+// The field is compared with its backend paths against the redacted fields and their backend paths,
+// hence a redacted field can neither be selected by its name nor by the path it is stored at in the backend.
+// Redactions without fields apply to the whole document except its metadata.
+// Selecting or sorting by a redacted field would allow to probe its values.
+func (r *Redactions) Redacted(field string) bool {
+	if r == nil {
+		return false
+	}
+
+	paths := backendPaths(field, r.fieldMap)
+	for _, rule := range r.rules {
+		if len(rule.Fields) == 0 {
+			if field != "metadata" && !strings.HasPrefix(field, "metadata.") {
+				return true
+			}
+
+			continue
+		}
+
+		for _, path := range paths {
+			for _, redacted := range rule.paths {
+				if path == redacted || strings.HasPrefix(path, redacted+".") || strings.HasPrefix(redacted, path+".") {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// backendPaths returns the field and the backend paths it is mapped to by the field map.
+// The fields are mapped the same way as the selectors of backend queries.
+func backendPaths(field string, fieldMap map[string][]string) []string {
+	paths := []string{field}
+	for from, fieldsTo := range fieldMap {
+		for _, to := range fieldsTo {
+			path := strings.TrimLeft(strings.Replace(field, from, to, -1), ".")
+			if path != field && path != "" {
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	return paths
+}
+
+// unredacted returns true if the user is allowed to get the unredacted subresource
+func (r *Redactor) unredacted(ctx context.Context, u user.Info) bool {
+	if Authorizer == nil {
+		return false
+	}
+
+	ns, _ := request.NamespaceFrom(ctx)
+	decision, _, err := Authorizer.Authorize(ctx, authorizer.AttributesRecord{
+		User:            u,
+		Verb:            "get",
+		Namespace:       ns,
+		APIGroup:        r.groupResource.Group,
+		Resource:        r.groupResource.Resource,
+		Subresource:     UnredactedSubresource,
+		ResourceRequest: true,
+	})
+
+	if err != nil {
+		klog.ErrorS(err, "failed to authorize unredacted access", "user", u.GetName(), "resource", r.groupResource.String())
+		return false
+	}
+
+	return decision == authorizer.DecisionAllow
+}
+
+func (rule redactionRule) appliesTo(u user.Info) bool {
+	groups := u.GetGroups()
+	if containsAny(rule.ExemptGroups, groups) {
+		return false
+	}
+
+	return len(rule.Groups) == 0 || containsAny(rule.Groups, groups)
+}
+
+func (rule redactionRule) apply(doc *gabs.Container) error {
+	// Redactions without fields apply to the whole document except its metadata which is required to identify the object
+	if len(rule.Fields) == 0 {
+		if obj, ok := doc.Data().(map[string]interface{}); ok {
+			for k, v := range obj {
+				if k != "metadata" {
+					obj[k] = rule.redactValue(v)
+				}
+			}
+		}
+
+		return nil
+	}
+
+	for _, field := range rule.Fields {
+		v := doc.Path(field)
+		if v == nil || v.Data() == nil {
+			continue
+		}
+
+		if len(rule.patterns) > 0 {
+			if _, err := doc.SetP(rule.redactValue(v.Data()), field); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		switch rule.Action {
+		case configv1alpha1.RedactionActionDrop:
+			_ = doc.DeleteP(field)
+		case configv1alpha1.RedactionActionHash:
+			b, err := json.Marshal(v.Data())
+			if err != nil {
+				return err
+			}
+
+			if _, err := doc.SetP(rule.hash(string(b)), field); err != nil {
+				return err
+			}
+		default:
+			if _, err := doc.SetP(redactionMask, field); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// redactValue replaces the pattern matches in all string values
+func (rule redactionRule) redactValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case string:
+		for _, re := range rule.patterns {
+			value = re.ReplaceAllStringFunc(value, rule.replace)
+		}
+
+		return value
+	case map[string]interface{}:
+		for k, child := range value {
+			value[k] = rule.redactValue(child)
+		}
+	case []interface{}:
+		for i, child := range value {
+			value[i] = rule.redactValue(child)
+		}
+	}
+
+	return v
+}
+
+func (rule redactionRule) replace(match string) string {
+	switch rule.Action {
+	case configv1alpha1.RedactionActionDrop:
+		return ""
+	case configv1alpha1.RedactionActionHash:
+		return rule.hash(match)
+	default:
+		return redactionMask
+	}
+}
+
+// hash returns the hmac-sha256 of the value keyed with the hash key of the rule
+func (rule redactionRule) hash(value string) string {
+	mac := hmac.New(sha256.New, rule.key)
+	mac.Write([]byte(value))
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func containsAny(list, values []string) bool {
+	for _, l := range list {
+		for _, v := range values {
+			if l == v {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,732 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/apiserver/pkg/storage/names"
+	"k8s.io/apiserver/pkg/warning"
+
+	corev1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+)
+
+const (
+	// defaultSearchPageSize is the page size of the results subresource if no limit is given
+	defaultSearchPageSize = 500
+
+	// maxSearchesPerUser is the maximum number of searches a user can keep at the same time
+	maxSearchesPerUser = 10
+
+	// defaultSearchTTL is the time a search is kept if the storage backend does not report its expiration
+	defaultSearchTTL = 24 * time.Hour
+)
+
+var ErrSearchNotSupported = errors.New("storage does not support searches")
+
+// Searcher is implemented by storages which support asynchronous searches
+type Searcher interface {
+	// SubmitSearch starts an asynchronous search and returns its id within the storage backend
+	SubmitSearch(ctx context.Context, options *metainternalversion.ListOptions) (string, error)
+
+	// SearchProgress returns the state of the search
+	SearchProgress(ctx context.Context, id string) (SearchProgress, error)
+
+	// SearchResults returns a page of the results of a completed search.
+	// The first page is read from the stored search, the following pages continue after the continue token of the previous page.
+	SearchResults(ctx context.Context, id string, options *metainternalversion.ListOptions) (runtime.Object, error)
+
+	// DeleteSearch discards the search and its results
+	DeleteSearch(ctx context.Context, id string) error
+}
+
+// SearchProgress is the state of a search within the storage backend
+type SearchProgress struct {
+	Running    bool
+	Expiration time.Time
+	Err        error
+}
+
+// searcherOf returns the searcher of a wrapped storage
+func searcherOf(storage rest.Storage, groupResource schema.GroupResource) (Searcher, error) {
+	if searcher, ok := storage.(Searcher); ok {
+		return searcher, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrSearchNotSupported, groupResource.String())
+}
+
+// SearchProvider resolves the storage of a resource which executes searches
+type SearchProvider interface {
+	Searcher(resource string) (Searcher, error)
+}
+
+type search struct {
+	obj      *corev1alpha1.Search
+	searcher Searcher
+	id       string
+	options  *metainternalversion.ListOptions
+	expires  time.Time
+}
+
+// searchStore holds the submitted searches.
+// Searches are kept in memory, the results are stored within the storage backend until they expire.
+// The apiserver must therefore run as a single replica, the helm chart enforces this.
+// Expired searches are pruned whenever the store is accessed.
+type searchStore struct {
+	mu       sync.Mutex
+	searches map[string]*search
+}
+
+var searches = &searchStore{
+	searches: make(map[string]*search),
+}
+
+// submit starts the search within the storage backend and stores it
+func (s *searchStore) submit(ctx context.Context, searcher Searcher, obj *corev1alpha1.Search, options *metainternalversion.ListOptions) (*corev1alpha1.Search, error) {
+	if obj.Name == "" {
+		prefix := obj.GenerateName
+		if prefix == "" {
+			prefix = "search-"
+		}
+
+		obj.Name = names.SimpleNameGenerator.GenerateName(prefix)
+	}
+
+	if user, ok := request.UserFrom(ctx); ok {
+		obj.Spec.User = user.GetName()
+	}
+
+	if err := s.admit(obj); err != nil {
+		return nil, err
+	}
+
+	// The first page of the results is collected by the search, the following pages are fetched on demand
+	submitOptions := options.DeepCopy()
+	submitOptions.Limit = defaultSearchPageSize
+	if obj.Spec.Limit > 0 && obj.Spec.Limit < submitOptions.Limit {
+		submitOptions.Limit = obj.Spec.Limit
+	}
+
+	ctx = request.WithNamespace(ctx, obj.Spec.Namespace)
+	id, err := searcher.SubmitSearch(ctx, submitOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	now := metav1.Now()
+	obj.CreationTimestamp = now
+	obj.Status = corev1alpha1.SearchStatus{
+		Phase:     corev1alpha1.SearchRunning,
+		StartTime: &now,
+	}
+
+	entry := &search{
+		obj:      obj,
+		searcher: searcher,
+		id:       id,
+		options:  options.DeepCopy(),
+		expires:  now.Add(defaultSearchTTL),
+	}
+
+	if progress, err := searcher.SearchProgress(ctx, id); err == nil && !progress.Expiration.IsZero() {
+		expiration := metav1.NewTime(progress.Expiration)
+		obj.Status.ExpirationTime = &expiration
+		entry.expires = progress.Expiration
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.admitLocked(obj); err != nil {
+		_ = searcher.DeleteSearch(ctx, id)
+		return nil, err
+	}
+
+	s.searches[obj.Name] = entry
+	return obj.DeepCopy(), nil
+}
+
+// admit verifies that the search name is not taken and the user has not reached the maximum number of searches
+func (s *searchStore) admit(obj *corev1alpha1.Search) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.admitLocked(obj)
+}
+
+func (s *searchStore) admitLocked(obj *corev1alpha1.Search) error {
+	s.pruneLocked(time.Now())
+
+	if _, ok := s.searches[obj.Name]; ok {
+		return apierrors.NewAlreadyExists(corev1alpha1.Resource("searches"), obj.Name)
+	}
+
+	var owned int
+	for _, entry := range s.searches {
+		if entry.obj.Spec.User == obj.Spec.User {
+			owned++
+		}
+	}
+
+	if owned >= maxSearchesPerUser {
+		return apierrors.NewTooManyRequests(fmt.Sprintf("at most %d searches are kept per user, delete a search before submitting a new one", maxSearchesPerUser), 0)
+	}
+
+	return nil
+}
+
+// pruneLocked removes the searches which expired within the storage backend
+func (s *searchStore) pruneLocked(now time.Time) {
+	for name, entry := range s.searches {
+		if now.After(entry.expires) {
+			delete(s.searches, name)
+		}
+	}
+}
+
+// get returns the search if it was submitted by the requesting user
+func (s *searchStore) get(ctx context.Context, name string) (*search, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(time.Now())
+
+	entry, ok := s.searches[name]
+	if !ok || !ownedBy(ctx, entry.obj) {
+		return nil, apierrors.NewNotFound(corev1alpha1.Resource("searches"), name)
+	}
+
+	return entry, nil
+}
+
+// list returns all searches submitted by the requesting user
+func (s *searchStore) list(ctx context.Context) []*search {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(time.Now())
+
+	var list []*search
+	for _, entry := range s.searches {
+		if ownedBy(ctx, entry.obj) {
+			list = append(list, entry)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].obj.Name < list[j].obj.Name
+	})
+
+	return list
+}
+
+func (s *searchStore) delete(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.searches, name)
+}
+
+// refresh updates the status of the search from the storage backend.
+// Searches which expired within the storage backend are removed.
+func (s *searchStore) refresh(ctx context.Context, entry *search) (*corev1alpha1.Search, error) {
+	progress, err := entry.searcher.SearchProgress(ctx, entry.id)
+	if apierrors.IsNotFound(err) {
+		s.delete(entry.obj.Name)
+		return nil, apierrors.NewNotFound(corev1alpha1.Resource("searches"), entry.obj.Name)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := &entry.obj.Status
+	if !progress.Expiration.IsZero() {
+		expiration := metav1.NewTime(progress.Expiration)
+		status.ExpirationTime = &expiration
+		entry.expires = progress.Expiration
+	}
+
+	switch {
+	case progress.Running:
+		status.Phase = corev1alpha1.SearchRunning
+	case progress.Err != nil:
+		status.Phase = corev1alpha1.SearchFailed
+		status.Error = progress.Err.Error()
+	default:
+		status.Phase = corev1alpha1.SearchCompleted
+	}
+
+	if !progress.Running && status.CompletionTime == nil {
+		now := metav1.Now()
+		status.CompletionTime = &now
+	}
+
+	return entry.obj.DeepCopy(), nil
+}
+
+func ownedBy(ctx context.Context, obj *corev1alpha1.Search) bool {
+	user, ok := request.UserFrom(ctx)
+	return ok && user.GetName() == obj.Spec.User
+}
+
+// searchSubmittedWarning returns the warning of a list request which was submitted as search
+func searchSubmittedWarning(obj *corev1alpha1.Search) string {
+	return fmt.Sprintf("the requested time range was submitted as search %s, follow its progress with `kubectl get searches %s`", obj.Name, obj.Name)
+}
+
+var _ rest.Scoper = &searchStorage{}
+var _ rest.Storage = &searchStorage{}
+var _ rest.Creater = &searchStorage{}
+var _ rest.Getter = &searchStorage{}
+var _ rest.Lister = &searchStorage{}
+var _ rest.GracefulDeleter = &searchStorage{}
+var _ rest.TableConvertor = &searchStorage{}
+
+var searchTableColumns = []metav1.TableColumnDefinition{
+	{Name: "NAME", Type: "string", Format: "name", Description: "The name of the search."},
+	{Name: "RESOURCE", Type: "string", Description: "The searched resource."},
+	{Name: "PHASE", Type: "string", Description: "Either Running, Completed or Failed."},
+	{Name: "AGE", Type: "string", Description: "The time since the search was submitted."},
+}
+
+// searchStorage is the storage of the searches resource
+type searchStorage struct {
+	provider SearchProvider
+}
+
+// NewSearchStorage returns the storage of the searches resource
+func NewSearchStorage(provider SearchProvider) rest.Storage {
+	return &searchStorage{
+		provider: provider,
+	}
+}
+
+func (s *searchStorage) New() runtime.Object {
+	return (&corev1alpha1.Search{}).New()
+}
+
+func (s *searchStorage) NewList() runtime.Object {
+	return &corev1alpha1.SearchList{}
+}
+
+func (s *searchStorage) NamespaceScoped() bool {
+	return false
+}
+
+func (s *searchStorage) Destroy() {
+}
+
+func (s *searchStorage) Create(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+	search, ok := obj.(*corev1alpha1.Search)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("not a search: %T", obj))
+	}
+
+	if createValidation != nil {
+		if err := createValidation(ctx, obj); err != nil {
+			return nil, err
+		}
+	}
+
+	searcher, err := s.provider.Searcher(search.Spec.Resource)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("resource %s can not be searched: %s", search.Spec.Resource, err.Error()))
+	}
+
+	if err := authorizeSearch(ctx, search); err != nil {
+		return nil, err
+	}
+
+	selector, err := labels.Parse(search.Spec.FieldSelector)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid field selector: %s", err.Error()))
+	}
+
+	return searches.submit(ctx, searcher, search, &metainternalversion.ListOptions{
+		LabelSelector: selector,
+		Limit:         search.Spec.Limit,
+	})
+}
+
+func (s *searchStorage) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	entry, err := searches.get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return searches.refresh(ctx, entry)
+}
+
+func (s *searchStorage) List(ctx context.Context, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	list := &corev1alpha1.SearchList{}
+	for _, entry := range searches.list(ctx) {
+		obj, err := searches.refresh(ctx, entry)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		list.Items = append(list.Items, *obj)
+	}
+
+	return list, nil
+}
+
+func (s *searchStorage) Delete(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+	entry, err := searches.get(ctx, name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if deleteValidation != nil {
+		if err := deleteValidation(ctx, entry.obj); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if err := entry.searcher.DeleteSearch(ctx, entry.id); err != nil && !apierrors.IsNotFound(err) {
+		return nil, false, err
+	}
+
+	searches.delete(name)
+	return entry.obj.DeepCopy(), true, nil
+}
+
+func (s *searchStorage) ConvertToTable(ctx context.Context, obj runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	table := &metav1.Table{
+		ColumnDefinitions: searchTableColumns,
+	}
+
+	var items []corev1alpha1.Search
+	switch obj := obj.(type) {
+	case *corev1alpha1.Search:
+		items = append(items, *obj)
+	case *corev1alpha1.SearchList:
+		items = obj.Items
+	}
+
+	for i := range items {
+		item := &items[i]
+		table.Rows = append(table.Rows, metav1.TableRow{
+			Object: runtime.RawExtension{Object: item},
+			Cells:  []interface{}{item.Name, item.Spec.Resource, string(item.Status.Phase), time.Since(item.CreationTimestamp.Time).Round(time.Second).String()},
+		})
+	}
+
+	return table, nil
+}
+
+// authorizeSearch verifies that the requesting user may list the searched resource
+func authorizeSearch(ctx context.Context, obj *corev1alpha1.Search) error {
+	if Authorizer == nil {
+		return nil
+	}
+
+	user, ok := request.UserFrom(ctx)
+	if !ok {
+		return apierrors.NewUnauthorized("no user found in request")
+	}
+
+	decision, reason, err := Authorizer.Authorize(ctx, authorizer.AttributesRecord{
+		User:            user,
+		Verb:            "list",
+		Namespace:       obj.Spec.Namespace,
+		APIGroup:        corev1alpha1.SchemeGroupVersion.Group,
+		APIVersion:      corev1alpha1.SchemeGroupVersion.Version,
+		Resource:        obj.Spec.Resource,
+		ResourceRequest: true,
+	})
+
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	if decision != authorizer.DecisionAllow {
+		return apierrors.NewForbidden(corev1alpha1.Resource(obj.Spec.Resource), "", fmt.Errorf("searching %s is not allowed: %s", obj.Spec.Resource, reason))
+	}
+
+	return nil
+}
+
+var _ rest.Storage = &searchResultsStorage{}
+var _ rest.Connecter = &searchResultsStorage{}
+
+// searchResultsStorage serves the results of completed searches page by page
+type searchResultsStorage struct{}
+
+// NewSearchResultsStorage returns the storage of the searches/results subresource
+func NewSearchResultsStorage() rest.Storage {
+	return &searchResultsStorage{}
+}
+
+func (s *searchResultsStorage) New() runtime.Object {
+	return &corev1alpha1.SearchResultsOptions{}
+}
+
+func (s *searchResultsStorage) Destroy() {
+}
+
+func (s *searchResultsStorage) NewConnectOptions() (runtime.Object, bool, string) {
+	return &corev1alpha1.SearchResultsOptions{}, false, ""
+}
+
+func (s *searchResultsStorage) ConnectMethods() []string {
+	return []string{http.MethodGet}
+}
+
+func (s *searchResultsStorage) Connect(ctx context.Context, name string, options runtime.Object, responder rest.Responder) (http.Handler, error) {
+	opts, ok := options.(*corev1alpha1.SearchResultsOptions)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid options object: %#v", options))
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		list, err := searchResultsPage(ctx, name, opts)
+		if err != nil {
+			responder.Error(err)
+			return
+		}
+
+		responder.Object(http.StatusOK, list)
+	}), nil
+}
+
+// searchResultsContinue is the continue token of the results subresource.
+// It holds the number of results returned so far and the continue token of the storage backend.
+type searchResultsContinue struct {
+	Offset   int64  `json:"offset"`
+	Continue string `json:"continue"`
+}
+
+// searchResultsPage returns the page of the search results starting at the continue token.
+// The pages are fetched from the storage backend, at most the limit of the search is returned in total.
+func searchResultsPage(ctx context.Context, name string, opts *corev1alpha1.SearchResultsOptions) (runtime.Object, error) {
+	entry, err := searches.get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := searches.refresh(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	switch obj.Status.Phase {
+	case corev1alpha1.SearchRunning:
+		return nil, apierrors.NewConflict(corev1alpha1.Resource("searches"), name, errors.New("the search has not completed yet"))
+	case corev1alpha1.SearchFailed:
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("the search failed: %s", obj.Status.Error))
+	}
+
+	var token searchResultsContinue
+	if opts.Continue != "" {
+		b, err := base64.RawURLEncoding.DecodeString(opts.Continue)
+		if err != nil || json.Unmarshal(b, &token) != nil || token.Offset < 0 || token.Continue == "" ||
+			(obj.Spec.Limit > 0 && token.Offset >= obj.Spec.Limit) {
+			return nil, apierrors.NewBadRequest("invalid continue token")
+		}
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultSearchPageSize
+	}
+
+	if obj.Spec.Limit > 0 && obj.Spec.Limit-token.Offset < limit {
+		limit = obj.Spec.Limit - token.Offset
+	}
+
+	options := entry.options.DeepCopy()
+	options.Limit = limit
+	options.Continue = token.Continue
+
+	list, err := entry.searcher.SearchResults(request.WithNamespace(ctx, obj.Spec.Namespace), entry.id, options)
+	if err != nil {
+		return nil, err
+	}
+
+	listMeta, err := meta.ListAccessor(list)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Offset += int64(meta.LenList(list))
+	token.Continue = listMeta.GetContinue()
+	listMeta.SetContinue("")
+
+	if token.Continue != "" && (obj.Spec.Limit == 0 || token.Offset < obj.Spec.Limit) {
+		b, err := json.Marshal(token)
+		if err != nil {
+			return nil, err
+		}
+
+		listMeta.SetContinue(base64.RawURLEncoding.EncodeToString(b))
+	}
+
+	return list, nil
+}
+
+var _ rest.Scoper = &searchThresholdStorage{}
+var _ rest.Storage = &searchThresholdStorage{}
+var _ rest.Lister = &searchThresholdStorage{}
+var _ rest.Watcher = &searchThresholdStorage{}
+var _ rest.TableConvertor = &searchThresholdStorage{}
+var _ Searcher = &searchThresholdStorage{}
+
+// searchThresholdStorage submits list requests as search if the requested time range exceeds the threshold
+type searchThresholdStorage struct {
+	storage       rest.Storage
+	searcher      Searcher
+	groupResource schema.GroupResource
+	threshold     time.Duration
+}
+
+// WithSearchThreshold wraps the storage so that list requests whose time range exceeds the threshold are submitted
+// as search to the storage. The request returns an empty list and a warning referencing the search.
+func WithSearchThreshold(storage rest.Storage, groupResource schema.GroupResource, threshold metav1.Duration) rest.Storage {
+	searcher, ok := storage.(Searcher)
+	if !ok || threshold.Duration == 0 {
+		return storage
+	}
+
+	return &searchThresholdStorage{
+		storage:       storage,
+		searcher:      searcher,
+		groupResource: groupResource,
+		threshold:     threshold.Duration,
+	}
+}
+
+func (s *searchThresholdStorage) New() runtime.Object {
+	return s.storage.New()
+}
+
+func (s *searchThresholdStorage) Destroy() {
+	s.storage.Destroy()
+}
+
+func (s *searchThresholdStorage) NewList() runtime.Object {
+	if lister, ok := s.storage.(rest.Lister); ok {
+		return lister.NewList()
+	}
+
+	return nil
+}
+
+func (s *searchThresholdStorage) NamespaceScoped() bool {
+	if scoper, ok := s.storage.(rest.Scoper); ok {
+		return scoper.NamespaceScoped()
+	}
+
+	return false
+}
+
+func (s *searchThresholdStorage) List(ctx context.Context, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	lister, ok := s.storage.(rest.Lister)
+	if !ok {
+		return nil, apierrors.NewMethodNotSupported(s.groupResource, "list")
+	}
+
+	resolver, ok := s.storage.(TimeRangeResolver)
+	if !ok {
+		return lister.List(ctx, options)
+	}
+
+	from, to, err := resolver.TimeRange(ctx, options)
+	if err != nil || to.Sub(from) <= s.threshold {
+		return lister.List(ctx, options)
+	}
+
+	ns, _ := request.NamespaceFrom(ctx)
+	obj := &corev1alpha1.Search{
+		Spec: corev1alpha1.SearchSpec{
+			Resource:  s.groupResource.Resource,
+			Namespace: ns,
+			Limit:     options.Limit,
+		},
+	}
+
+	if options.LabelSelector != nil {
+		obj.Spec.FieldSelector = options.LabelSelector.String()
+	}
+
+	obj, err = searches.submit(ctx, s.searcher, obj, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// The request returns an empty list, the search is referenced by a warning so typed clients and the table output keep working
+	warning.AddWarning(ctx, "", searchSubmittedWarning(obj))
+	return lister.NewList(), nil
+}
+
+func (s *searchThresholdStorage) Watch(ctx context.Context, options *metainternalversion.ListOptions) (watch.Interface, error) {
+	if watcher, ok := s.storage.(rest.Watcher); ok {
+		return watcher.Watch(ctx, options)
+	}
+
+	return nil, apierrors.NewMethodNotSupported(s.groupResource, "watch")
+}
+
+func (s *searchThresholdStorage) SubmitSearch(ctx context.Context, options *metainternalversion.ListOptions) (string, error) {
+	return s.searcher.SubmitSearch(ctx, options)
+}
+
+func (s *searchThresholdStorage) SearchProgress(ctx context.Context, id string) (SearchProgress, error) {
+	return s.searcher.SearchProgress(ctx, id)
+}
+
+func (s *searchThresholdStorage) SearchResults(ctx context.Context, id string, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	return s.searcher.SearchResults(ctx, id, options)
+}
+
+func (s *searchThresholdStorage) DeleteSearch(ctx context.Context, id string) error {
+	return s.searcher.DeleteSearch(ctx, id)
+}
+
+func (s *searchThresholdStorage) ConvertToTable(ctx context.Context, obj runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	if convertor, ok := s.storage.(rest.TableConvertor); ok {
+		return convertor.ConvertToTable(ctx, obj, tableOptions)
+	}
+
+	return rest.NewDefaultTableConvertor(s.groupResource).ConvertToTable(ctx, obj, tableOptions)
+}
+
+// Query resolves the backend query from the backend storage
+func (s *searchThresholdStorage) Query(ctx context.Context, options *metainternalversion.ListOptions) ([]string, string, error) {
+	if resolver, ok := s.storage.(QueryResolver); ok {
+		return resolver.Query(ctx, options)
+	}
+
+	return nil, "", fmt.Errorf("%s does not support query resolution", s.groupResource.String())
+}
+
+// TimeRange resolves the time range from the backend storage
+func (s *searchThresholdStorage) TimeRange(ctx context.Context, options *metainternalversion.ListOptions) (time.Time, time.Time, error) {
+	if resolver, ok := s.storage.(TimeRangeResolver); ok {
+		return resolver.TimeRange(ctx, options)
+	}
+
+	return time.Time{}, time.Time{}, fmt.Errorf("%s does not support time ranges", s.groupResource.String())
+}
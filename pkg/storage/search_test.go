@@ -0,0 +1,388 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/apiserver/pkg/warning"
+
+	configv1alpha1 "github.com/raffis/kjournal/pkg/apis/config/v1alpha1"
+	corev1alpha1 "github.com/raffis/kjournal/pkg/apis/core/v1alpha1"
+)
+
+// fakeSearcher is a backend storage which supports searches.
+// Each search has the given number of results, the continue token is the offset of the next page.
+type fakeSearcher struct {
+	*fakeStorage
+	results    int
+	expiration time.Time
+	submitted  []*metainternalversion.ListOptions
+	deleted    []string
+}
+
+func (s *fakeSearcher) SubmitSearch(ctx context.Context, options *metainternalversion.ListOptions) (string, error) {
+	s.submitted = append(s.submitted, options)
+	return fmt.Sprintf("id-%d", len(s.submitted)), nil
+}
+
+func (s *fakeSearcher) SearchProgress(ctx context.Context, id string) (SearchProgress, error) {
+	return SearchProgress{Expiration: s.expiration}, nil
+}
+
+func (s *fakeSearcher) SearchResults(ctx context.Context, id string, options *metainternalversion.ListOptions) (runtime.Object, error) {
+	start := 0
+	if options.Continue != "" {
+		start, _ = strconv.Atoi(options.Continue)
+	}
+
+	end := start + int(options.Limit)
+	if end > s.results {
+		end = s.results
+	}
+
+	list := &metav1.PartialObjectMetadataList{}
+	for i := start; i < end; i++ {
+		list.Items = append(list.Items, metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: strconv.Itoa(i)}})
+	}
+
+	if end < s.results {
+		list.Continue = strconv.Itoa(end)
+	}
+
+	return list, nil
+}
+
+func (s *fakeSearcher) DeleteSearch(ctx context.Context, id string) error {
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+// warningRecorder records the warnings of a request
+type warningRecorder []string
+
+func (r *warningRecorder) AddWarning(agent, text string) {
+	*r = append(*r, text)
+}
+
+// fakeSearchProvider resolves the searcher of the test resource
+type fakeSearchProvider struct {
+	searcher Searcher
+}
+
+func (p *fakeSearchProvider) Searcher(resource string) (Searcher, error) {
+	if resource != testGroupResource.Resource || p.searcher == nil {
+		return nil, fmt.Errorf("%w: %s", ErrSearchNotSupported, resource)
+	}
+
+	return p.searcher, nil
+}
+
+func TestSearchSubmission(t *testing.T) {
+	now := time.Now()
+
+	type call struct {
+		list            bool
+		selector        string
+		limit           int64
+		expectedError   func(err error) bool
+		expectedWarning bool
+	}
+
+	tests := []struct {
+		name              string
+		apiBinding        configv1alpha1.API
+		from              time.Time
+		calls             []call
+		expectedSubmitted int
+		expectedEntries   []string
+	}{
+		{
+			name: "Search submissions are rate limited",
+			apiBinding: configv1alpha1.API{
+				Limits: &configv1alpha1.Limits{
+					LimitSpec: configv1alpha1.LimitSpec{QPS: 1, Burst: 1},
+				},
+			},
+			calls: []call{
+				{},
+				{expectedError: apierrors.IsTooManyRequests},
+			},
+			expectedSubmitted: 1,
+		},
+		{
+			name: "Search submissions exceeding the maximum limit are rejected",
+			apiBinding: configv1alpha1.API{
+				Limits: &configv1alpha1.Limits{
+					LimitSpec: configv1alpha1.LimitSpec{MaxLimit: 100},
+				},
+			},
+			calls: []call{
+				{limit: 500, expectedError: apierrors.IsBadRequest},
+				{limit: 50},
+			},
+			expectedSubmitted: 1,
+		},
+		{
+			name: "List requests exceeding the search threshold are limited before they are submitted",
+			apiBinding: configv1alpha1.API{
+				AsyncSearchThreshold: metav1.Duration{Duration: time.Hour},
+				Limits: &configv1alpha1.Limits{
+					LimitSpec: configv1alpha1.LimitSpec{MaxTimeRange: metav1.Duration{Duration: time.Hour}},
+				},
+			},
+			from: now.Add(-2 * time.Hour),
+			calls: []call{
+				{list: true, expectedError: apierrors.IsBadRequest},
+				{expectedError: apierrors.IsBadRequest},
+			},
+		},
+		{
+			name: "Search submissions are access logged",
+			apiBinding: configv1alpha1.API{
+				AsyncSearchThreshold: metav1.Duration{Duration: time.Hour},
+			},
+			from: now.Add(-2 * time.Hour),
+			calls: []call{
+				{selector: "payload.level=error"},
+				{list: true, expectedWarning: true},
+			},
+			expectedSubmitted: 2,
+			expectedEntries:   []string{"search payload.level=error", "list "},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			searches = &searchStore{searches: make(map[string]*search)}
+
+			backend := &fakeSearcher{fakeStorage: &fakeStorage{from: test.from, to: now}}
+			entries := make(chanAccessLogger, len(test.calls))
+			storage, searcher := wrapStorage(backend, testGroupResource, &test.apiBinding, entries)
+			searchStorage := NewSearchStorage(&fakeSearchProvider{searcher: searcher})
+
+			for i, c := range test.calls {
+				var (
+					obj      runtime.Object
+					err      error
+					warnings warningRecorder
+				)
+
+				if c.list {
+					obj, err = storage.(rest.Lister).List(warning.WithWarningRecorder(withUser("jane"), &warnings), &metainternalversion.ListOptions{
+						LabelSelector: labels.Everything(),
+					})
+				} else {
+					_, err = searchStorage.(rest.Creater).Create(withUser("jane"), &corev1alpha1.Search{
+						Spec: corev1alpha1.SearchSpec{
+							Resource:      testGroupResource.Resource,
+							FieldSelector: c.selector,
+							Limit:         c.limit,
+						},
+					}, nil, &metav1.CreateOptions{})
+				}
+
+				if c.expectedError != nil {
+					assert.Assert(t, c.expectedError(err), "call %d: unexpected error %v", i, err)
+					continue
+				}
+
+				assert.NilError(t, err, "call %d", i)
+
+				if c.expectedWarning {
+					list, ok := obj.(*metav1.PartialObjectMetadataList)
+					assert.Assert(t, ok, "call %d: expected list, got %T", i, obj)
+					assert.Equal(t, 0, len(list.Items))
+					assert.Equal(t, 1, len(warnings))
+					assert.Assert(t, strings.HasPrefix(warnings[0], "the requested time range was submitted as search search-"), warnings[0])
+				}
+			}
+
+			assert.Equal(t, test.expectedSubmitted, len(backend.submitted))
+
+			var logged []string
+			for len(entries) > 0 {
+				entry := <-entries
+				logged = append(logged, entry.Verb+" "+entry.Selector)
+			}
+
+			if test.expectedEntries != nil {
+				assert.DeepEqual(t, test.expectedEntries, logged)
+			}
+		})
+	}
+}
+
+// submitSearch creates a search as the given user
+func submitSearch(t *testing.T, searchStorage rest.Storage, ctx context.Context, limit int64) *corev1alpha1.Search {
+	obj, err := searchStorage.(rest.Creater).Create(ctx, &corev1alpha1.Search{
+		Spec: corev1alpha1.SearchSpec{
+			Resource: testGroupResource.Resource,
+			Limit:    limit,
+		},
+	}, nil, &metav1.CreateOptions{})
+
+	assert.NilError(t, err)
+	return obj.(*corev1alpha1.Search)
+}
+
+func TestSearchOwnership(t *testing.T) {
+	searches = &searchStore{searches: make(map[string]*search)}
+	backend := &fakeSearcher{fakeStorage: &fakeStorage{}, results: 1}
+	searchStorage := NewSearchStorage(&fakeSearchProvider{searcher: backend})
+
+	obj := submitSearch(t, searchStorage, withUser("jane"), 0)
+	assert.Equal(t, "jane", obj.Spec.User)
+
+	_, err := searchStorage.(rest.Getter).Get(withUser("bob"), obj.Name, &metav1.GetOptions{})
+	assert.Assert(t, apierrors.IsNotFound(err), "unexpected error %v", err)
+
+	_, err = searchResultsPage(withUser("bob"), obj.Name, &corev1alpha1.SearchResultsOptions{})
+	assert.Assert(t, apierrors.IsNotFound(err), "unexpected error %v", err)
+
+	_, _, err = searchStorage.(rest.GracefulDeleter).Delete(withUser("bob"), obj.Name, nil, &metav1.DeleteOptions{})
+	assert.Assert(t, apierrors.IsNotFound(err), "unexpected error %v", err)
+
+	list, err := searchStorage.(rest.Lister).List(withUser("bob"), &metainternalversion.ListOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(list.(*corev1alpha1.SearchList).Items))
+
+	list, err = searchStorage.(rest.Lister).List(withUser("jane"), &metainternalversion.ListOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(list.(*corev1alpha1.SearchList).Items))
+
+	_, _, err = searchStorage.(rest.GracefulDeleter).Delete(withUser("jane"), obj.Name, nil, &metav1.DeleteOptions{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"id-1"}, backend.deleted)
+
+	_, err = searchStorage.(rest.Getter).Get(withUser("jane"), obj.Name, &metav1.GetOptions{})
+	assert.Assert(t, apierrors.IsNotFound(err), "unexpected error %v", err)
+}
+
+func TestSearchResultsPaging(t *testing.T) {
+	tests := []struct {
+		name                string
+		results             int
+		searchLimit         int64
+		pageLimit           int64
+		expectedSubmitLimit int64
+		expectedPages       [][]string
+	}{
+		{
+			name:                "Results are paged from the storage backend",
+			results:             7,
+			pageLimit:           3,
+			expectedSubmitLimit: defaultSearchPageSize,
+			expectedPages:       [][]string{{"0", "1", "2"}, {"3", "4", "5"}, {"6"}},
+		},
+		{
+			name:                "Results are capped to the limit of the search",
+			results:             7,
+			searchLimit:         5,
+			pageLimit:           3,
+			expectedSubmitLimit: 5,
+			expectedPages:       [][]string{{"0", "1", "2"}, {"3", "4"}},
+		},
+		{
+			name:                "Results are not truncated beyond the first page of the search",
+			results:             defaultSearchPageSize + 2,
+			searchLimit:         defaultSearchPageSize + 1,
+			pageLimit:           defaultSearchPageSize,
+			expectedSubmitLimit: defaultSearchPageSize,
+			expectedPages:       [][]string{nil, {strconv.Itoa(defaultSearchPageSize)}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			searches = &searchStore{searches: make(map[string]*search)}
+			backend := &fakeSearcher{fakeStorage: &fakeStorage{}, results: test.results}
+			searchStorage := NewSearchStorage(&fakeSearchProvider{searcher: backend})
+
+			obj := submitSearch(t, searchStorage, withUser("jane"), test.searchLimit)
+			assert.Equal(t, test.expectedSubmitLimit, backend.submitted[0].Limit)
+
+			opts := &corev1alpha1.SearchResultsOptions{Limit: test.pageLimit}
+			for i, expected := range test.expectedPages {
+				page, err := searchResultsPage(withUser("jane"), obj.Name, opts)
+				assert.NilError(t, err)
+
+				list := page.(*metav1.PartialObjectMetadataList)
+				if expected != nil {
+					var names []string
+					for _, item := range list.Items {
+						names = append(names, item.Name)
+					}
+
+					assert.DeepEqual(t, expected, names)
+				}
+
+				assert.Equal(t, i < len(test.expectedPages)-1, list.Continue != "", "page %d: unexpected continue token %q", i, list.Continue)
+				opts.Continue = list.Continue
+			}
+		})
+	}
+
+	t.Run("Invalid continue tokens are rejected", func(t *testing.T) {
+		searches = &searchStore{searches: make(map[string]*search)}
+		backend := &fakeSearcher{fakeStorage: &fakeStorage{}, results: 1}
+		searchStorage := NewSearchStorage(&fakeSearchProvider{searcher: backend})
+		obj := submitSearch(t, searchStorage, withUser("jane"), 0)
+
+		_, err := searchResultsPage(withUser("jane"), obj.Name, &corev1alpha1.SearchResultsOptions{Continue: "500"})
+		assert.Assert(t, apierrors.IsBadRequest(err), "unexpected error %v", err)
+	})
+}
+
+func TestSearchExpiry(t *testing.T) {
+	searches = &searchStore{searches: make(map[string]*search)}
+	backend := &fakeSearcher{fakeStorage: &fakeStorage{}, results: 1}
+	searchStorage := NewSearchStorage(&fakeSearchProvider{searcher: backend})
+
+	t.Run("Searches without a backend expiration are kept for the default ttl", func(t *testing.T) {
+		submitSearch(t, searchStorage, withUser("jane"), 0)
+
+		for _, entry := range searches.searches {
+			assert.Assert(t, entry.expires.After(time.Now().Add(defaultSearchTTL-time.Minute)))
+		}
+	})
+
+	t.Run("Searches are limited per user", func(t *testing.T) {
+		for i := 1; i < maxSearchesPerUser; i++ {
+			submitSearch(t, searchStorage, withUser("jane"), 0)
+		}
+
+		_, err := searchStorage.(rest.Creater).Create(withUser("jane"), &corev1alpha1.Search{
+			Spec: corev1alpha1.SearchSpec{Resource: testGroupResource.Resource},
+		}, nil, &metav1.CreateOptions{})
+		assert.Assert(t, apierrors.IsTooManyRequests(err), "unexpected error %v", err)
+		assert.Equal(t, maxSearchesPerUser, len(backend.submitted))
+
+		submitSearch(t, searchStorage, withUser("bob"), 0)
+	})
+
+	t.Run("Expired searches are pruned", func(t *testing.T) {
+		backend.expiration = time.Now().Add(-time.Second)
+
+		list, err := searchStorage.(rest.Lister).List(withUser("jane"), &metainternalversion.ListOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, maxSearchesPerUser, len(list.(*corev1alpha1.SearchList).Items))
+
+		list, err = searchStorage.(rest.Lister).List(withUser("jane"), &metainternalversion.ListOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, 0, len(list.(*corev1alpha1.SearchList).Items))
+
+		backend.expiration = time.Time{}
+		submitSearch(t, searchStorage, withUser("jane"), 0)
+	})
+}